@@ -2,12 +2,14 @@
 package main
 
 import (
+	"bufio"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"reflect"
 	"runtime"
 	"strings"
 	"time"
@@ -21,7 +23,60 @@ type output struct {
 	w    io.Writer
 }
 
-func dispatch(app *tt.TT, args []string, w io.Writer) error {
+// prompt asks the user for confirmations when the input is a terminal.
+type prompt struct {
+	r           *bufio.Reader
+	w           io.Writer
+	interactive bool
+}
+
+func newPrompt(in io.Reader, w io.Writer) prompt {
+	interactive := false
+	if f, ok := in.(*os.File); ok {
+		if stat, err := f.Stat(); err == nil {
+			interactive = stat.Mode()&os.ModeCharDevice != 0
+		}
+	}
+
+	return prompt{r: bufio.NewReader(in), w: w, interactive: interactive}
+}
+
+// confirm asks a yes/no question, it is always false when non-interactive.
+func (p prompt) confirm(question string) bool {
+	if !p.interactive {
+		return false
+	}
+
+	fmt.Fprintf(p.w, "%s [y/N] ", question)
+	answer, err := p.r.ReadString('\n')
+	if err != nil {
+		return false
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
+
+// ask reads a line of input, it is always empty when non-interactive.
+func (p prompt) ask(question string) string {
+	if !p.interactive {
+		return ""
+	}
+
+	fmt.Fprintf(p.w, "%s ", question)
+	answer, err := p.r.ReadString('\n')
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(answer)
+}
+
+func dispatch(app *tt.TT, args []string, in prompt, w io.Writer) error {
 	fset := flag.NewFlagSet("main", flag.ExitOnError)
 	fset.Usage = func() {
 		fmt.Fprint(w, t("Please run `man tt` to obtain the documentation.\n"))
@@ -36,6 +91,30 @@ func dispatch(app *tt.TT, args []string, w io.Writer) error {
 	loadFixtures := fset.Bool("fixture", false, t("clears the database and fills it with dev data"))
 	showReport := fset.Bool("report", false, t("weekly report"))
 	showTagReport := fset.Bool("tag-report", false, t("global tag report"))
+	editRules := fset.Bool("rules", false, t("lists and edits the overtime rules"))
+	editSchedule := fset.Bool("schedule", false, t("shows or sets the weekly work schedule"))
+	editHolidays := fset.Bool("holidays", false, t("lists and edits the public holidays"))
+	editLeave := fset.Bool("leave", false, t("lists and edits leave, shows leave balances"))
+	splitTask := fset.Bool("split", false, t("splits a task in two: [ID] AT [DESC [TAGS]]"))
+	mergeTasks := fset.Bool("merge", false, t("merges contiguous tasks: TASK TASK..."))
+	showGaps := fset.Bool("gaps", false, t("lists and fills the untracked time between tasks"))
+	at := fset.String("at", "", t("starts or stops the task at the given time instead of now"))
+	ago := fset.String("ago", "", t("starts or stops the task the given duration ago"))
+	addTask := fset.Bool("add", false, t("adds a stopped task: START STOP DESC [TAGS]"))
+	resumeTask := fset.Bool("resume", false, t("resumes a recent task: [INDEX|SEARCH]"))
+	showRecent := fset.Bool("recent", false, t("lists the recent tasks that can be resumed"))
+	timebox := fset.String("for", "", t("starts a task planned to end after the given duration"))
+	pushTask := fset.Bool("push", false, t("suspends the current task and starts an interrupting one"))
+	popTask := fset.Bool("pop", false, t("stops the current task and resumes the last suspended one"))
+	overlap := fset.String("overlap", "", t("how to resolve overlaps with -add: reject, trim or shift"))
+	fixForgotten := fset.Bool("fix-forgotten", false, t("truncates the tasks that were not stopped in time"))
+	assumeYes := fset.Bool("yes", false, t("applies the changes without asking, with -fix-forgotten and -add"))
+	undoChange := fset.Bool("undo", false, t("reverts the last change"))
+	redoChange := fset.Bool("redo", false, t("applies again the last reverted change"))
+	showHistory := fset.Bool("history", false, t("shows the changes made to a task: ID"))
+	checkDB := fset.Bool("fsck", false, t("checks the database and repairs it: [repair]"))
+	showJournal := fset.Bool("journal", false, t("lists the changes that can be undone"))
+	migrateStatus := fset.Bool("migrate-status", false, t("shows the database migration status"))
 	jsonOutput := fset.Bool("json", false, t("outputs JSON"))
 
 	if err := fset.Parse(args); err != nil {
@@ -44,6 +123,54 @@ func dispatch(app *tt.TT, args []string, w io.Writer) error {
 
 	out := output{json: *jsonOutput, w: w}
 
+	if *showUI {
+		app.SetSource(tt.SourceTUI)
+	} else {
+		app.SetSource(tt.SourceCLI)
+	}
+
+	when, err := parseWhen(*at, *ago)
+	if err != nil {
+		return err
+	}
+
+	if *timebox != "" && (*pushTask || *resumeTask) {
+		return tt.InvalidInputError(t("-for cannot be combined with -push or -resume"))
+	}
+
+	if *migrateStatus {
+		return showMigrationStatus(app, out)
+	}
+
+	if err := app.Migrate(); err != nil {
+		return err
+	}
+
+	// Before anything else changes the DB, so that the user's last change is
+	// the one undone.
+	switch {
+	case *undoChange, *redoChange:
+		return undo(app, *redoChange, out)
+	case *showJournal:
+		return journal(app, out)
+	case *checkDB:
+		return fsck(app, fset.Args(), in, out)
+	}
+
+	if err := expire(app, out); err != nil {
+		return err
+	}
+
+	if *fixForgotten {
+		return fixForgottenTasks(app, *assumeYes, in, out)
+	}
+
+	if !*showUI && !out.json {
+		if err := checkForgottenTask(app, in, out); err != nil {
+			return err
+		}
+	}
+
 	switch {
 	case *showVersion:
 		fmt.Fprintf(out.w, "tt version %s %s/%s\n", Version, runtime.GOOS, runtime.GOARCH)
@@ -52,13 +179,39 @@ func dispatch(app *tt.TT, args []string, w io.Writer) error {
 		ui := ui.New(app)
 		return ui.Run()
 	case *stopTask:
-		return stop(app, out)
+		return stop(app, when, out)
 	case *loadFixtures:
 		return app.Fixture()
 	case *showReport:
 		return report(app, out)
 	case *showTagReport:
 		return tagReport(app, out)
+	case *editRules:
+		return rules(app, fset.Args(), out)
+	case *editSchedule:
+		return schedule(app, fset.Args(), out)
+	case *editHolidays:
+		return holidays(app, fset.Args(), out)
+	case *editLeave:
+		return leave(app, fset.Args(), out)
+	case *splitTask:
+		return split(app, fset.Args(), out)
+	case *mergeTasks:
+		return merge(app, fset.Args(), out)
+	case *showHistory:
+		return history(app, fset.Args(), out)
+	case *showGaps:
+		return gaps(app, fset.Args(), in, out)
+	case *resumeTask:
+		return resume(app, fset.Args(), when, out)
+	case *showRecent:
+		return recent(app, out)
+	case *pushTask:
+		return push(app, fset.Args(), when, out)
+	case *popTask:
+		return pop(app, when, out)
+	case *addTask:
+		return add(app, fset.Args(), *overlap, *assumeYes, in, out)
 	case *replaceTask:
 		return replace(app, fset.Args(), out)
 	case *startTask:
@@ -67,12 +220,63 @@ func dispatch(app *tt.TT, args []string, w io.Writer) error {
 		if len(fset.Args()) == 0 {
 			return showCurrent(app, out)
 		}
-		return start(app, fset.Args(), out)
+		return start(app, fset.Args(), when, *timebox, out)
+	}
+}
+
+// expire stops the current task if its planned end is past.
+func expire(app *tt.TT, out output) error {
+	expired, err := app.ExpireTask()
+	if err != nil || expired == nil {
+		return err
+	}
+
+	if !out.json {
+		fmt.Fprintf(
+			out.w,
+			t("Stopped task \"%s\" at its planned end (%s).\n"),
+			expired.Description,
+			expired.StoppedAt.Format("15:04"),
+		)
+	}
+
+	return nil
+}
+
+// parseWhen returns the time given to the -at or -ago flags, or now.
+func parseWhen(at, ago string) (time.Time, error) {
+	now := time.Now()
+
+	switch {
+	case at != "" && ago != "":
+		return time.Time{}, tt.InvalidInputError(t("-at and -ago cannot be used together"))
+	case ago != "":
+		d, err := util.ParseDuration(ago)
+		if err != nil {
+			return time.Time{}, tt.InvalidInputError(fmt.Sprintf(t("invalid duration for -ago: %s"), err))
+		}
+		return now.Add(-d), nil
+	case at != "":
+		return parseTime(at, now)
+	default:
+		return now, nil
+	}
+}
+
+// parseTime parses a user-provided time, times of day without a day fall on
+// the day of base.
+func parseTime(str string, base time.Time) (time.Time, error) {
+	v, err := util.ParseTimeOn(str, time.Now(), base)
+	if err != nil {
+		return time.Time{}, tt.InvalidInputError(err.Error())
 	}
+
+	return v, nil
 }
 
 type currentTaskOutput struct {
 	Task                *tt.Task
+	Suspended           []tt.Task
 	DailyUntilOvertime  *time.Duration
 	WeeklyUntilOvertime *time.Duration
 }
@@ -88,10 +292,28 @@ func (c currentTaskOutput) String() string {
 			strings.Join(c.Task.Tags, " "),
 			util.FormatDuration(time.Since(c.Task.StartedAt)),
 		)
+
+		if !c.Task.Deadline.IsZero() {
+			fmt.Fprintf(
+				&b,
+				t("Planned to end at %s, %s left.\n"),
+				c.Task.Deadline.Format("15:04"),
+				util.FormatDuration(time.Until(c.Task.Deadline)),
+			)
+		}
 	} else {
 		fmt.Fprint(&b, t("There is no task running.\n"))
 	}
 
+	if len(c.Suspended) > 0 {
+		fmt.Fprintf(
+			&b,
+			t("%d suspended task(s), `tt -pop` will resume: %s\n"),
+			len(c.Suspended),
+			strings.Join(append([]string{c.Suspended[0].Description}, c.Suspended[0].Tags...), " "),
+		)
+	}
+
 	if c.DailyUntilOvertime == nil || c.WeeklyUntilOvertime == nil {
 		return b.String()
 	}
@@ -157,6 +379,10 @@ func showCurrent(app *tt.TT, out output) error {
 		ret = tt.ExitCodeError(1)
 	}
 
+	if formatter.Suspended, err = app.GetStack(); err != nil {
+		return err
+	}
+
 	if daily, weekly, err := app.GetDurationLeft(); err == nil {
 		formatter.DailyUntilOvertime = &daily
 		formatter.WeeklyUntilOvertime = &weekly
@@ -176,9 +402,26 @@ func showCurrent(app *tt.TT, out output) error {
 	return ret
 }
 
+func start(app *tt.TT, args []string, at time.Time, timebox string, out output) error {
+	if timebox == "" {
+		prev, next, err := app.StartAt(strings.Join(args, " "), at)
+		return writeStartMessages(out, prev, next, err)
+	}
+
+	d, err := util.ParseDuration(timebox)
+	if err != nil {
+		return tt.InvalidInputError(fmt.Sprintf(t("invalid duration for -for: %s"), err))
+	}
+
+	prev, next, err := app.StartFor(strings.Join(args, " "), at, d)
+
+	return writeStartMessages(out, prev, next, err)
+}
+
+// writeStartMessages outputs the result of a Start call, ErrContinue is not
+// considered an error.
 // nolint: cyclop // looks ok to me
-func start(app *tt.TT, args []string, out output) error {
-	prev, next, err := app.Start(strings.Join(args, " "))
+func writeStartMessages(out output, prev, next *tt.Task, err error) error {
 	if err != nil {
 		if errors.Is(err, tt.ErrContinue) {
 			fmt.Fprintf(
@@ -201,11 +444,22 @@ func start(app *tt.TT, args []string, out output) error {
 		if len(next.Tags) > 0 {
 			fmt.Fprintf(out.w, t("With tags: \"%s\"\n"), strings.Join(next.Tags, " "))
 		}
+		if time.Since(next.StartedAt) >= time.Minute {
+			fmt.Fprintf(out.w, t("Started at %s.\n"), next.StartedAt.Format("15:04"))
+		}
+		if !next.Deadline.IsZero() {
+			fmt.Fprintf(out.w, t("Planned to end at %s.\n"), next.Deadline.Format("15:04"))
+		}
 	} else if prev != nil && prev.ID == next.ID {
-		if len(next.Tags) == 0 {
-			fmt.Fprint(out.w, "Removed tags from current task.\n")
-		} else {
-			fmt.Fprintf(out.w, "Replaced tags from current task: %s\n", strings.Join(next.Tags, " "))
+		if !reflect.DeepEqual(prev.Tags, next.Tags) {
+			if len(next.Tags) == 0 {
+				fmt.Fprint(out.w, "Removed tags from current task.\n")
+			} else {
+				fmt.Fprintf(out.w, "Replaced tags from current task: %s\n", strings.Join(next.Tags, " "))
+			}
+		}
+		if !next.Deadline.Equal(prev.Deadline) {
+			fmt.Fprintf(out.w, t("Current task planned to end at %s.\n"), next.Deadline.Format("15:04"))
 		}
 	}
 
@@ -239,8 +493,8 @@ func writeStoppedTaskMessage(out output, task tt.Task) {
 	)
 }
 
-func stop(app *tt.TT, out output) error {
-	stopped, err := app.Stop()
+func stop(app *tt.TT, at time.Time, out output) error {
+	stopped, err := app.StopAt(at)
 	if err != nil {
 		if errors.Is(err, tt.ErrNoCurrentTask) {
 			fmt.Fprint(out.w, t("There is no running task.\n"))
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"tt/internal/tt"
+	"tt/internal/util"
+)
+
+// Usage:
+//
+//	tt -add [-overlap POLICY] [-yes] START STOP DESC [TAGS]
+func add(app *tt.TT, args []string, overlap string, yes bool, in prompt, out output) error {
+	if len(args) < 3 {
+		return tt.InvalidInputError(t("usage: -add START STOP DESC [TAGS]"))
+	}
+
+	start, err := parseTime(args[0], time.Now())
+	if err != nil {
+		return err
+	}
+
+	stop, err := parseTime(args[1], start)
+	if err != nil {
+		return err
+	}
+
+	policy, err := getOverlapPolicy(app, overlap)
+	if err != nil {
+		return err
+	}
+
+	raw := strings.Join(args[2:], " ")
+
+	task, changes, err := app.AddTaskWith(raw, start, stop, policy, true)
+	if err != nil {
+		return err
+	}
+
+	// Overlapping tasks are only changed once confirmed, or with -yes.
+	if len(changes) > 0 && !yes {
+		if out.json {
+			// The task was only added by the dry run.
+			pending := *task
+			pending.ID = 0
+			if err := writeAddedTask(out, &pending, changes, true); err != nil {
+				return err
+			}
+			return tt.ExitCodeError(1)
+		}
+
+		writeTaskChanges(out, changes)
+		if !in.interactive {
+			return tt.InvalidInputError(t("-add asks for confirmation, use -yes to change the overlapping tasks without a terminal"))
+		}
+		if !in.confirm(t("Apply these changes?")) {
+			fmt.Fprint(out.w, t("Nothing was changed.\n"))
+			return nil
+		}
+	}
+
+	task, changes, err = app.AddTaskWith(raw, start, stop, policy, false)
+	if err != nil {
+		return err
+	}
+
+	if out.json {
+		return writeAddedTask(out, task, changes, false)
+	}
+
+	fmt.Fprintf(
+		out.w,
+		t("Added task \"%s\" from %s to %s (%s).\n"),
+		task.Description,
+		task.StartedAt.Format("2006-01-02 15:04"),
+		task.StoppedAt.Format("15:04"),
+		util.FormatDuration(task.Duration()),
+	)
+	if len(task.Tags) > 0 {
+		fmt.Fprintf(out.w, t("With tags: \"%s\"\n"), strings.Join(task.Tags, " "))
+	}
+
+	return nil
+}
+
+// writeAddedTask outputs the task as JSON with the changed tasks, pending if
+// they were not applied.
+func writeAddedTask(out output, task *tt.Task, changes []tt.TaskChange, pending bool) error {
+	enc := json.NewEncoder(out.w)
+	return enc.Encode(struct {
+		*tt.Task
+		Changes []tt.TaskChange `json:",omitempty"`
+		Pending bool            `json:",omitempty"`
+	}{task, changes, pending})
+}
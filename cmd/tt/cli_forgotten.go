@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+	"tt/internal/tt"
+	"tt/internal/util"
+)
+
+// checkForgottenTask warns about a running task that was most likely not
+// stopped in time and offers to truncate it.
+func checkForgottenTask(app *tt.TT, in prompt, out output) error {
+	task, err := app.GetForgottenCurrentTask()
+	if err != nil || task == nil {
+		return err
+	}
+
+	fmt.Fprintf(
+		out.w,
+		t("Warning: task \"%s\" has been running since %s (%s), it was probably not stopped.\n"),
+		task.Description,
+		task.StartedAt.Format("2006-01-02 15:04"),
+		util.FormatDuration(task.Duration()),
+	)
+
+	if !in.confirm(fmt.Sprintf(t("Stop it at %s?"), task.SuggestedStop.Format("2006-01-02 15:04"))) {
+		if !in.interactive {
+			fmt.Fprint(out.w, t("Run `tt -fix-forgotten` to stop it.\n"))
+		}
+		return nil
+	}
+
+	if _, err := app.FixForgottenTask(task.ID, task.SuggestedStop); err != nil {
+		return err
+	}
+	writeFixedForgottenMessage(out, task.Task, task.SuggestedStop)
+
+	return nil
+}
+
+// fixForgottenTasks truncates all the forgotten tasks, asking for each one
+// when interactive. Otherwise yes must be given to truncate them all.
+func fixForgottenTasks(app *tt.TT, yes bool, in prompt, out output) error {
+	if !in.interactive && !yes {
+		return tt.InvalidInputError(t("-fix-forgotten asks for confirmation, use -yes to fix all the tasks without a terminal"))
+	}
+
+	tasks, err := app.GetForgottenTasks()
+	if err != nil {
+		return err
+	}
+
+	var fixed []tt.Task
+	for _, v := range tasks {
+		if !yes && !in.confirm(fmt.Sprintf(
+			t("Task \"%s\" lasted %s from %s, stop it at %s?"),
+			v.Description,
+			util.FormatDuration(v.Duration()),
+			v.StartedAt.Format("2006-01-02 15:04"),
+			v.SuggestedStop.Format("2006-01-02 15:04"),
+		)) {
+			continue
+		}
+
+		task, err := app.FixForgottenTask(v.ID, v.SuggestedStop)
+		if err != nil {
+			return err
+		}
+		fixed = append(fixed, *task)
+
+		if !out.json {
+			writeFixedForgottenMessage(out, v.Task, v.SuggestedStop)
+		}
+	}
+
+	if out.json {
+		enc := json.NewEncoder(out.w)
+		return enc.Encode(fixed)
+	}
+
+	if len(tasks) == 0 {
+		fmt.Fprint(out.w, t("No forgotten task found.\n"))
+	}
+
+	return nil
+}
+
+func writeFixedForgottenMessage(out output, task tt.Task, stop time.Time) {
+	fmt.Fprintf(
+		out.w,
+		t("Stopped task \"%s\" at %s.\n"),
+		task.Description,
+		stop.Format("2006-01-02 15:04"),
+	)
+}
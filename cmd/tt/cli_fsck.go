@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"tt/internal/tt"
+)
+
+// Exit codes of -fsck, the database is fine otherwise.
+const (
+	fsckRepaired   = 1 // anomalies were found and all repaired
+	fsckUnrepaired = 2 // some anomalies are left
+)
+
+// Usage:
+//
+//	tt -fsck [repair]
+func fsck(app *tt.TT, args []string, in prompt, out output) error {
+	repair := len(args) == 1 && args[0] == "repair"
+	if len(args) > 0 && !repair {
+		return tt.InvalidInputError(t("usage: -fsck [repair]"))
+	}
+
+	anomalies, err := app.Fsck(repair)
+	if err != nil {
+		return err
+	}
+
+	if !repair && !out.json && countRepairable(anomalies) > 0 {
+		writeAnomalies(out, anomalies)
+
+		if !in.confirm(fmt.Sprintf(t("Repair %d anomalies?"), countRepairable(anomalies))) {
+			return tt.ExitCodeError(fsckUnrepaired)
+		}
+
+		if anomalies, err = app.Fsck(true); err != nil {
+			return err
+		}
+	}
+
+	if out.json {
+		enc := json.NewEncoder(out.w)
+		if err := enc.Encode(anomalies); err != nil {
+			return err
+		}
+	} else {
+		writeAnomalies(out, anomalies)
+	}
+
+	if len(anomalies) == 0 {
+		return nil
+	}
+
+	for _, v := range anomalies {
+		if !v.Repaired {
+			return tt.ExitCodeError(fsckUnrepaired)
+		}
+	}
+
+	return tt.ExitCodeError(fsckRepaired)
+}
+
+func countRepairable(anomalies []tt.Anomaly) int {
+	var ret int
+	for _, v := range anomalies {
+		if v.Repair != "" && !v.Repaired {
+			ret++
+		}
+	}
+
+	return ret
+}
+
+func writeAnomalies(out output, anomalies []tt.Anomaly) {
+	for _, v := range anomalies {
+		switch {
+		case v.Repaired:
+			fmt.Fprintf(out.w, t("repaired: %s, %s\n"), v.Message, v.Repair)
+		case v.Repair != "":
+			fmt.Fprintf(out.w, t("%s, repair: %s\n"), v.Message, v.Repair)
+		default:
+			fmt.Fprintf(out.w, t("%s, needs a manual fix\n"), v.Message)
+		}
+	}
+
+	if len(anomalies) == 0 {
+		fmt.Fprint(out.w, t("No anomaly found.\n"))
+	}
+}
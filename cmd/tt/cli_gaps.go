@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"tt/internal/tt"
+	"tt/internal/util"
+)
+
+// Usage:
+//
+//	tt -gaps [FROM [TO]]
+//	tt -gaps fill [FROM [TO]]
+//	tt -gaps breaks [BREAK...|none]
+func gaps(app *tt.TT, args []string, in prompt, out output) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "fill":
+			return fillGaps(app, args[1:], in, out)
+		case "breaks":
+			return breaks(app, args[1:], out)
+		}
+	}
+
+	list, err := getGaps(app, args)
+	if err != nil {
+		return err
+	}
+
+	if out.json {
+		enc := json.NewEncoder(out.w)
+		return enc.Encode(list)
+	}
+
+	var (
+		total time.Duration
+		day   time.Time
+	)
+	for _, v := range list {
+		if d := util.GetStartOfDay(v.Start); !d.Equal(day) {
+			day = d
+			fmt.Fprintln(out.w, day.Format("2006-01-02 Mon"))
+		}
+
+		fmt.Fprintf(out.w, "  %s\n", formatGap(v))
+		total += v.Duration()
+	}
+
+	if len(list) == 0 {
+		fmt.Fprint(out.w, t("No untracked time found.\n"))
+	} else {
+		fmt.Fprintf(out.w, t("Untracked: %s\n"), util.FormatDuration(total))
+	}
+
+	return nil
+}
+
+// getGaps returns the gaps of the FROM and TO days included, by default
+// from the start of the week to today.
+func getGaps(app *tt.TT, args []string) ([]tt.Gap, error) {
+	from := util.GetStartOfWeek(time.Now())
+	to := util.GetStartOfDay(time.Now())
+
+	switch len(args) {
+	case 0:
+	case 1, 2:
+		var err error
+		if from, err = parseDay(args[0]); err != nil {
+			return nil, err
+		}
+
+		if len(args) == 2 {
+			if to, err = parseDay(args[1]); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, tt.InvalidInputError(t("usage: -gaps [FROM [TO]]"))
+	}
+
+	return app.GetGaps(from, to.AddDate(0, 0, 1))
+}
+
+func formatGap(gap tt.Gap) string {
+	ret := fmt.Sprintf(
+		"%s - %s  %6s",
+		gap.Start.Format("15:04"),
+		gap.End.Format("15:04"),
+		util.FormatDuration(gap.Duration()),
+	)
+
+	if gap.Previous != nil {
+		ret += fmt.Sprintf(t("  after \"%s\""), gap.Previous.Description)
+	}
+	if gap.Next != nil {
+		ret += fmt.Sprintf(t("  before \"%s\""), gap.Next.Description)
+	}
+
+	return ret
+}
+
+// fillGaps asks what to do with each gap.
+func fillGaps(app *tt.TT, args []string, in prompt, out output) error {
+	if !in.interactive {
+		return tt.InvalidInputError(t("-gaps fill needs to be run from a terminal"))
+	}
+
+	list, err := getGaps(app, args)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range list {
+		fmt.Fprintf(out.w, "%s %s\n", v.Start.Format("2006-01-02 Mon"), formatGap(v))
+		if v.Previous != nil {
+			fmt.Fprintf(out.w, t("  p) extend \"%s\" until %s\n"), v.Previous.Description, v.End.Format("15:04"))
+		}
+		if v.Next != nil {
+			fmt.Fprintf(out.w, t("  n) start \"%s\" at %s\n"), v.Next.Description, v.Start.Format("15:04"))
+		}
+		fmt.Fprint(out.w, t("  r) assign to a recent task\n  a) add a new task\n"))
+
+		task, err := fillGap(app, v, in.ask(t("Choice (empty to skip):")), in, out)
+		if err != nil {
+			return err
+		}
+
+		if task != nil {
+			fmt.Fprintf(
+				out.w,
+				t("Task \"%s\" now covers %s to %s.\n\n"),
+				task.Description,
+				task.StartedAt.Format("15:04"),
+				task.StoppedAt.Format("15:04"),
+			)
+		} else {
+			fmt.Fprintln(out.w)
+		}
+	}
+
+	if len(list) == 0 {
+		fmt.Fprint(out.w, t("No untracked time found.\n"))
+	}
+
+	return nil
+}
+
+func fillGap(app *tt.TT, gap tt.Gap, choice string, in prompt, out output) (*tt.Task, error) {
+	switch choice {
+	case "p", "n":
+		return app.ExtendOverGap(gap, choice == "p")
+	case "r":
+		candidates, err := app.GetRecentTasks()
+		if err != nil {
+			return nil, err
+		}
+
+		for i, v := range candidates {
+			fmt.Fprintf(out.w, "  %2d  %s %s\n", i+1, v.Description, strings.Join(v.Tags, " "))
+		}
+
+		i, err := strconv.Atoi(in.ask(t("Task number:")))
+		if err != nil || i < 1 || i > len(candidates) {
+			return nil, nil
+		}
+
+		return app.AssignGap(gap, candidates[i-1].ID)
+	case "a":
+		raw := in.ask(t("Description and tags:"))
+		if raw == "" {
+			return nil, nil
+		}
+
+		return app.AddTask(raw, gap.Start, gap.End)
+	default:
+		return nil, nil
+	}
+}
+
+// Usage:
+//
+//	tt -gaps breaks [BREAK...|none]
+func breaks(app *tt.TT, args []string, out output) error {
+	if len(args) > 0 {
+		var list []tt.Break
+		if len(args) != 1 || args[0] != "none" {
+			for _, v := range args {
+				b, err := tt.ParseBreak(v)
+				if err != nil {
+					return err
+				}
+				list = append(list, b)
+			}
+		}
+
+		if err := app.SetBreaks(list); err != nil {
+			return err
+		}
+	}
+
+	list, err := app.GetBreaks()
+	if err != nil {
+		return err
+	}
+
+	if out.json {
+		names := make([]string, 0, len(list))
+		for _, v := range list {
+			names = append(names, v.String())
+		}
+
+		enc := json.NewEncoder(out.w)
+		return enc.Encode(names)
+	}
+
+	for _, v := range list {
+		fmt.Fprintln(out.w, v)
+	}
+	if len(list) == 0 {
+		fmt.Fprint(out.w, t("No breaks are set.\n"))
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"tt/internal/tt"
+	"tt/internal/util"
+)
+
+// Usage:
+//
+//	tt -history ID
+func history(app *tt.TT, args []string, out output) error {
+	if len(args) != 1 {
+		return tt.InvalidInputError(t("usage: -history ID"))
+	}
+
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return tt.InvalidInputError(fmt.Sprintf(t("invalid task ID: %s"), args[0]))
+	}
+
+	entries, err := app.GetTaskHistory(id)
+	if errors.Is(err, tt.ErrInvalidTaskID) {
+		return tt.InvalidInputError(fmt.Sprintf(t("unknown task ID: %d"), id))
+	}
+	if err != nil {
+		return err
+	}
+
+	if out.json {
+		enc := json.NewEncoder(out.w)
+		return enc.Encode(entries)
+	}
+
+	for _, v := range entries {
+		fmt.Fprintf(
+			out.w,
+			"%s  %-6s  %-9s  %s  %6s  %s %s\n",
+			v.ChangedAt.Format("2006-01-02 15:04:05"),
+			v.Kind,
+			v.Source,
+			formatTaskSpan(v.Task),
+			util.FormatDuration(v.Task.Duration()),
+			v.Task.Description,
+			strings.Join(v.Task.Tags, " "),
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"tt/internal/tt"
+)
+
+// Usage:
+//
+//	tt -holidays [YEAR]
+//	tt -holidays add DATE NAME
+//	tt -holidays rm ID
+//	tt -holidays import FILE
+//	tt -holidays calendar [NAME|none]
+func holidays(app *tt.TT, args []string, out output) error {
+	if len(args) == 0 {
+		return listHolidays(app, time.Now().Year(), out)
+	}
+
+	switch args[0] {
+	case "add":
+		return addHoliday(app, args[1:], out)
+	case "rm":
+		return deleteHoliday(app, args[1:], out)
+	case "import":
+		return importHolidays(app, args[1:], out)
+	case "calendar":
+		return holidayCalendar(app, args[1:], out)
+	}
+
+	year, err := strconv.Atoi(args[0])
+	if err != nil || len(args) > 1 {
+		return tt.InvalidInputError(fmt.Sprintf("unknown -holidays command: %s", args[0]))
+	}
+
+	return listHolidays(app, year, out)
+}
+
+func listHolidays(app *tt.TT, year int, out output) error {
+	holidays, err := app.GetHolidays(year)
+	if err != nil {
+		return err
+	}
+
+	if out.json {
+		enc := json.NewEncoder(out.w)
+		return enc.Encode(holidays)
+	}
+
+	for _, v := range holidays {
+		id := "-"
+		if v.ID != 0 {
+			id = strconv.FormatInt(v.ID, 10)
+		}
+
+		fmt.Fprintf(out.w, "%4s  %s  %s  %s\n", id, v.Day.Format(dateFormat), v.Day.Format("Mon"), v.Name)
+	}
+
+	return nil
+}
+
+func addHoliday(app *tt.TT, args []string, out output) error {
+	if len(args) < 2 {
+		return tt.InvalidInputError(t("usage: -holidays add DATE NAME"))
+	}
+
+	day, err := parseDay(args[0])
+	if err != nil {
+		return err
+	}
+
+	holiday, err := app.AddHoliday(day, strings.Join(args[1:], " "))
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out.w, t("Added holiday #%d on %s: %s.\n"), holiday.ID, holiday.Day.Format(dateFormat), holiday.Name)
+
+	return nil
+}
+
+func deleteHoliday(app *tt.TT, args []string, out output) error {
+	if len(args) != 1 {
+		return tt.InvalidInputError(t("usage: -holidays rm ID"))
+	}
+
+	id, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := app.DeleteHoliday(id); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out.w, t("Removed holiday #%d.\n"), id)
+
+	return nil
+}
+
+func importHolidays(app *tt.TT, args []string, out output) error {
+	if len(args) != 1 {
+		return tt.InvalidInputError(t("usage: -holidays import FILE"))
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return tt.InvalidInputError(fmt.Sprintf("unable to open calendar: %s", err))
+	}
+	defer f.Close()
+
+	count, err := app.ImportHolidays(f)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out.w, t("Imported %d days.\n"), count)
+
+	return nil
+}
+
+func holidayCalendar(app *tt.TT, args []string, out output) error {
+	switch len(args) {
+	case 0:
+		name, err := app.HolidayCalendar()
+		if err != nil {
+			return err
+		}
+
+		if name == "" {
+			name = "none"
+		}
+
+		fmt.Fprintf(out.w, t("Current calendar: %s, available: %s.\n"), name, strings.Join(tt.HolidayCalendars(), ", "))
+
+		return nil
+	case 1:
+		name := args[0]
+		if name == "none" {
+			name = ""
+		}
+
+		return app.SetHolidayCalendar(name)
+	default:
+		return tt.InvalidInputError(t("usage: -holidays calendar [NAME|none]"))
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"tt/internal/tt"
+)
+
+func undo(app *tt.TT, redo bool, out output) error {
+	replay, message := app.Undo, t("Undone")
+	if redo {
+		replay, message = app.Redo, t("Redone")
+	}
+
+	entry, err := replay()
+	if errors.Is(err, tt.ErrNothingToUndo) || errors.Is(err, tt.ErrNothingToRedo) {
+		return tt.InvalidInputError(err.Error())
+	}
+	if err != nil {
+		return err
+	}
+
+	if out.json {
+		enc := json.NewEncoder(out.w)
+		return enc.Encode(entry)
+	}
+
+	fmt.Fprintf(out.w, "%s: %s\n", message, formatJournalEntry(*entry))
+
+	return nil
+}
+
+func journal(app *tt.TT, out output) error {
+	entries, err := app.GetJournal()
+	if err != nil {
+		return err
+	}
+
+	if out.json {
+		enc := json.NewEncoder(out.w)
+		return enc.Encode(entries)
+	}
+
+	for _, v := range entries {
+		state := "      "
+		if v.Undone {
+			state = t("undone")
+		}
+
+		fmt.Fprintf(out.w, "%4d  %s  %s\n", v.ID, state, formatJournalEntry(v))
+	}
+	if len(entries) == 0 {
+		fmt.Fprint(out.w, t("The journal is empty.\n"))
+	}
+
+	return nil
+}
+
+// formatJournalEntry describes the changes of a journal entry, rows without a
+// label (eg. tags of a task) are only counted.
+func formatJournalEntry(entry tt.JournalEntry) string {
+	var (
+		changes []string
+		others  int
+		seen    = map[string]bool{}
+	)
+
+	for _, v := range entry.Changes {
+		if v.Label == "" {
+			others++
+			continue
+		}
+
+		change := fmt.Sprintf("%s %s \"%s\"", v.Kind, v.Table, v.Label)
+		if !seen[change] {
+			seen[change] = true
+			changes = append(changes, change)
+		}
+	}
+
+	switch {
+	case others == 1:
+		changes = append(changes, t("1 other change"))
+	case others > 1:
+		changes = append(changes, fmt.Sprintf(t("%d other changes"), others))
+	}
+
+	return entry.CreatedAt.Format("2006-01-02 15:04:05") + "  " + strings.Join(changes, ", ")
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+	"time"
+	"tt/internal/tt"
+)
+
+// Usage:
+//
+//	tt -leave [YEAR]
+//	tt -leave add FROM [TO] [TYPE] [half]
+//	tt -leave rm ID
+//	tt -leave balance [YEAR]
+//	tt -leave allowance TYPE DAYS [MAX_CARRY_OVER]
+func leave(app *tt.TT, args []string, out output) error {
+	if len(args) == 0 {
+		return listLeave(app, time.Now().Year(), out)
+	}
+
+	switch args[0] {
+	case "add":
+		return addLeave(app, args[1:], out)
+	case "rm":
+		return deleteLeave(app, args[1:], out)
+	case "balance":
+		year, err := parseOptionalYear(args[1:])
+		if err != nil {
+			return err
+		}
+
+		return leaveBalance(app, year, out)
+	case "allowance":
+		return leaveAllowance(app, args[1:], out)
+	}
+
+	year, err := parseOptionalYear(args)
+	if err != nil {
+		return tt.InvalidInputError(fmt.Sprintf("unknown -leave command: %s", args[0]))
+	}
+
+	return listLeave(app, year, out)
+}
+
+func parseOptionalYear(args []string) (int, error) {
+	switch len(args) {
+	case 0:
+		return time.Now().Year(), nil
+	case 1:
+		year, err := strconv.Atoi(args[0])
+		if err != nil {
+			return 0, tt.InvalidInputError(fmt.Sprintf("invalid year: %s", args[0]))
+		}
+
+		return year, nil
+	default:
+		return 0, tt.InvalidInputError(t("too many arguments"))
+	}
+}
+
+func listLeave(app *tt.TT, year int, out output) error {
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.Now().Location())
+	leaves, err := app.GetLeaves(start, start.AddDate(1, 0, -1))
+	if err != nil {
+		return err
+	}
+
+	if out.json {
+		enc := json.NewEncoder(out.w)
+		return enc.Encode(leaves)
+	}
+
+	for _, v := range leaves {
+		fmt.Fprintf(out.w, "%4d  %s  %s  %-6s  %s\n", v.ID, v.Day.Format(dateFormat), v.Day.Format("Mon"), v.Type, formatPortion(v.Portion))
+	}
+
+	return nil
+}
+
+func formatPortion(v float64) string {
+	if v == 1 {
+		return t("full day")
+	}
+
+	return t("half day")
+}
+
+func addLeave(app *tt.TT, args []string, out output) error {
+	var (
+		dates     []time.Time
+		leaveType = tt.LeavePaid
+		portion   = 1.0
+	)
+
+	for _, v := range args {
+		if v == "half" {
+			portion = 0.5
+			continue
+		}
+
+		if day, err := parseDay(v); err == nil && len(dates) < 2 {
+			dates = append(dates, day)
+			continue
+		}
+
+		var err error
+		if leaveType, err = tt.ParseLeaveType(v); err != nil {
+			return err
+		}
+	}
+
+	switch len(dates) {
+	case 0:
+		return tt.InvalidInputError(t("usage: -leave add FROM [TO] [TYPE] [half]"))
+	case 1:
+		dates = append(dates, dates[0])
+	}
+
+	leaves, err := app.AddLeave(dates[0], dates[1], leaveType, portion)
+	if err != nil {
+		return err
+	}
+
+	if out.json {
+		enc := json.NewEncoder(out.w)
+		return enc.Encode(leaves)
+	}
+
+	for _, v := range leaves {
+		fmt.Fprintf(out.w, t("Added %s leave #%d on %s (%s).\n"), v.Type, v.ID, v.Day.Format(dateFormat), formatPortion(v.Portion))
+	}
+
+	return nil
+}
+
+func deleteLeave(app *tt.TT, args []string, out output) error {
+	if len(args) != 1 {
+		return tt.InvalidInputError(t("usage: -leave rm ID"))
+	}
+
+	id, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := app.DeleteLeave(id); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out.w, t("Removed leave #%d.\n"), id)
+
+	return nil
+}
+
+func leaveBalance(app *tt.TT, year int, out output) error {
+	balances, err := app.GetLeaveBalance(year)
+	if err != nil {
+		return err
+	}
+
+	if out.json {
+		enc := json.NewEncoder(out.w)
+		return enc.Encode(balances)
+	}
+
+	days := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	w := tabwriter.NewWriter(out.w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(w, t("%d\tAllowance\tCarried over\tTaken\tRemaining\t\n"), year)
+	for _, v := range balances {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t\n", v.Type, days(v.Allowance), days(v.CarryOver), days(v.Taken), days(v.Remaining))
+	}
+
+	return w.Flush()
+}
+
+func leaveAllowance(app *tt.TT, args []string, out output) error {
+	if len(args) < 2 || len(args) > 3 {
+		return tt.InvalidInputError(t("usage: -leave allowance TYPE DAYS [MAX_CARRY_OVER]"))
+	}
+
+	leaveType, err := tt.ParseLeaveType(args[0])
+	if err != nil {
+		return err
+	}
+
+	values := make([]float64, 2)
+	for i, v := range args[1:] {
+		if values[i], err = strconv.ParseFloat(v, 64); err != nil {
+			return tt.InvalidInputError(fmt.Sprintf("invalid number of days: %s", v))
+		}
+	}
+
+	if err := app.SetLeaveAllowance(leaveType, values[0], values[1]); err != nil {
+		return err
+	}
+
+	return leaveBalance(app, time.Now().Year(), out)
+}
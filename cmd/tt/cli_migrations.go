@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"tt/internal/tt"
+)
+
+type migrationStatusOutput struct {
+	Version int
+	Latest  int
+	Steps   []tt.MigrationStep
+}
+
+func showMigrationStatus(app *tt.TT, out output) error {
+	cur, steps, err := app.MigrationStatus()
+	if err != nil {
+		return err
+	}
+
+	if out.json {
+		enc := json.NewEncoder(out.w)
+		return enc.Encode(migrationStatusOutput{Version: cur, Latest: len(steps), Steps: steps})
+	}
+
+	fmt.Fprintf(out.w, t("Database version: %d (latest: %d)\n"), cur, len(steps))
+
+	pending := 0
+	for _, v := range steps {
+		status := t("applied")
+		if !v.Applied {
+			status = t("pending")
+			pending++
+		}
+		fmt.Fprintf(out.w, "%3d %-8s %s\n", v.Version, status, v.Name)
+	}
+
+	if pending == 0 {
+		fmt.Fprint(out.w, t("The database is up to date.\n"))
+	} else {
+		fmt.Fprintf(out.w, t("%d pending migration(s), they will be applied by the next tt command.\n"), pending)
+	}
+
+	return nil
+}
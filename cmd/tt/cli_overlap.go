@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"tt/internal/tt"
+	"tt/internal/util"
+)
+
+// getOverlapPolicy returns the policy given with -overlap, or the configured
+// one if empty.
+func getOverlapPolicy(app *tt.TT, name string) (tt.OverlapPolicy, error) {
+	if name == "" {
+		return app.GetOverlapPolicy()
+	}
+
+	return tt.ParseOverlapPolicy(name)
+}
+
+// writeTaskChanges lists the changes made to resolve overlaps.
+func writeTaskChanges(out output, changes []tt.TaskChange) {
+	fmt.Fprint(out.w, t("The following tasks overlap and will be changed:\n"))
+	for _, v := range changes {
+		if v.After == nil {
+			fmt.Fprintf(out.w, t("  deleted \"%s\" %s\n"), v.Before.Description, formatTaskSpan(v.Before))
+			continue
+		}
+
+		fmt.Fprintf(
+			out.w,
+			t("  \"%s\" %s -> %s\n"),
+			v.Before.Description,
+			formatTaskSpan(v.Before),
+			formatTaskSpan(*v.After),
+		)
+	}
+}
+
+func formatTaskSpan(task tt.Task) string {
+	return util.FormatSpan(task.StartedAt, task.StoppedAt, t("now"))
+}
@@ -55,17 +55,18 @@ func report(app *tt.TT, out output) error {
 
 // nolint:funlen // no need to split/abstract too much over this, embrace the
 // spaghetti (uncooked).
+// Only the days with a target or some work done are shown.
 // Example output:
 // Week #17 from 2021-04-26 to 2021-05-02
 //  00:00    00:00    00:00    00:00    00:00
 //  00:00    00:00    00:00    00:00    00:00
 //  07h48m   07h48m   07h48m   07h48m   07h48m   39h00m
+// (07h48m) (07h48m) (07h48m) (07h48m) (07h48m) ( 39h00m)
 // +01h20m  +00h00m  +00h00m  +00h00m  +00h00m  +00h00m (+00h00m)
 //   Mon.     Tue.     Wed.     Thu.     Fri.    Total
 func printWeeklyReport(out output, r tt.Report, total tt.ReportEntry) {
 	var (
 		b          strings.Builder
-		showDays   = 7
 		blank      = "         "
 		_, isoWeek = r.Accumulated.Day.ISOWeek()
 	)
@@ -85,13 +86,20 @@ func printWeeklyReport(out output, r tt.Report, total tt.ReportEntry) {
 
 	var dailyReport [7]tt.ReportEntry
 	for _, v := range r.Daily {
-		dailyReport[(v.Day.Weekday()+6)%7] = v
+		dailyReport[util.WeekdayOffset(v.Day.Weekday())] = v
 	}
 
-	b.Grow(300) // a little over expected exact length
+	showDays := make([]int, 0, len(dailyReport))
+	for i, v := range dailyReport {
+		if v.Target > 0 || v.WorkDuration > 0 {
+			showDays = append(showDays, i)
+		}
+	}
+
+	b.Grow(360) // a little over expected exact length
 
 	// Start hour
-	for i := 0; i < showDays; i++ {
+	for _, i := range showDays {
 		dr := dailyReport[i]
 		if dr.WorkDuration <= 0 {
 			b.WriteString(blank)
@@ -103,7 +111,7 @@ func printWeeklyReport(out output, r tt.Report, total tt.ReportEntry) {
 	b.WriteRune('\n')
 
 	// End hour
-	for i := 0; i < showDays; i++ {
+	for _, i := range showDays {
 		dr := dailyReport[i]
 		if dr.WorkDuration <= 0 {
 			b.WriteString(blank)
@@ -115,7 +123,7 @@ func printWeeklyReport(out output, r tt.Report, total tt.ReportEntry) {
 	b.WriteRune('\n')
 
 	// Duration
-	for i := 0; i < showDays; i++ {
+	for _, i := range showDays {
 		dr := dailyReport[i]
 		if dr.WorkDuration <= 0 {
 			b.WriteString(blank)
@@ -128,8 +136,21 @@ func printWeeklyReport(out output, r tt.Report, total tt.ReportEntry) {
 	fmt.Fprintf(&b, " (%7s)", util.FormatDuration(total.WorkDuration))
 	b.WriteRune('\n')
 
+	// Target
+	for _, i := range showDays {
+		dr := dailyReport[i]
+		if dr.Target <= 0 {
+			b.WriteString(blank)
+			continue
+		}
+
+		fmt.Fprintf(&b, " (%s)", util.FormatFixedDuration(dr.Target))
+	}
+	fmt.Fprintf(&b, " (%7s)", util.FormatFixedDuration(r.Accumulated.Target))
+	b.WriteRune('\n')
+
 	// Daily over/under time
-	for i := 0; i < showDays; i++ {
+	for _, i := range showDays {
 		dr := dailyReport[i]
 		over := dr.Overtime + dr.InLieu
 		switch {
@@ -148,11 +169,17 @@ func printWeeklyReport(out output, r tt.Report, total tt.ReportEntry) {
 	fmt.Fprintf(&b, " (%7s)", util.FormatSignedFixedDuration(totalDeltaSum))
 	b.WriteRune('\n')
 
-	b.WriteString("   Mon.     Tue.     Wed.     Thu.     Fri.     Sat.     Sun.     Total\n\n")
+	for _, i := range showDays {
+		fmt.Fprintf(&b, "   %s  ", weekdayNames[i])
+	}
+	b.WriteString("   Total\n\n")
 
 	fmt.Fprint(out.w, b.String())
 }
 
+// Indexed by util.WeekdayOffset.
+var weekdayNames = [7]string{"Mon.", "Tue.", "Wed.", "Thu.", "Fri.", "Sat.", "Sun."}
+
 func tagReport(app *tt.TT, out output) error {
 	report, err := app.GetTagReport()
 	if err != nil {
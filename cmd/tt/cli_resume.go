@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"tt/internal/tt"
+)
+
+type resumeOutput struct {
+	Resumed    *tt.Task
+	Candidates []tt.Task
+}
+
+// Usage:
+//
+//	tt -resume [INDEX|SEARCH]
+func resume(app *tt.TT, args []string, at time.Time, out output) error {
+	candidates, err := app.GetRecentTasks()
+	if err != nil {
+		return err
+	}
+
+	prev, next, err := app.Resume(strings.Join(args, " "), at)
+
+	if out.json {
+		if err != nil && !errors.Is(err, tt.ErrContinue) {
+			return err
+		}
+
+		enc := json.NewEncoder(out.w)
+		return enc.Encode(resumeOutput{Resumed: next, Candidates: candidates})
+	}
+
+	return writeStartMessages(out, prev, next, err)
+}
+
+func recent(app *tt.TT, out output) error {
+	candidates, err := app.GetRecentTasks()
+	if err != nil {
+		return err
+	}
+
+	if out.json {
+		enc := json.NewEncoder(out.w)
+		return enc.Encode(candidates)
+	}
+
+	for i, v := range candidates {
+		fmt.Fprintf(
+			out.w,
+			"%2d  %s  %s %s\n",
+			i+1,
+			v.StartedAt.Format("2006-01-02 15:04"),
+			v.Description,
+			strings.Join(v.Tags, " "),
+		)
+	}
+	if len(candidates) == 0 {
+		fmt.Fprint(out.w, t("There is no task to resume.\n"))
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+	"time"
+	"tt/internal/tt"
+	"tt/internal/util"
+)
+
+// Usage:
+//
+//	tt -rules
+//	tt -rules add RULE VALUE [DATE]
+//	tt -rules end ID [DATE]
+//	tt -rules rm ID
+func rules(app *tt.TT, args []string, out output) error {
+	if len(args) == 0 {
+		return listRules(app, out)
+	}
+
+	switch args[0] {
+	case "add":
+		return addRule(app, args[1:], out)
+	case "end":
+		return endRule(app, args[1:], out)
+	case "rm":
+		return deleteRule(app, args[1:], out)
+	default:
+		return tt.InvalidInputError(fmt.Sprintf("unknown -rules command: %s", args[0]))
+	}
+}
+
+func listRules(app *tt.TT, out output) error {
+	entries, err := app.GetRules()
+	if err != nil {
+		return err
+	}
+
+	if out.json {
+		enc := json.NewEncoder(out.w)
+		return enc.Encode(entries)
+	}
+
+	day := func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+
+		return t.Format(dateFormat)
+	}
+
+	w := tabwriter.NewWriter(out.w, 0, 0, 2, ' ', 0)
+	fmt.Fprint(w, t("ID\tRule\tValue\tFrom\tUntil\n"))
+	for _, v := range entries {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", v.ID, v.Rule, tt.FormatRuleValue(v.Rule, v.Value), day(v.Start), day(v.End))
+	}
+
+	return w.Flush()
+}
+
+func addRule(app *tt.TT, args []string, out output) error {
+	if len(args) < 2 || len(args) > 3 {
+		return tt.InvalidInputError(t("usage: -rules add RULE VALUE [DATE]"))
+	}
+
+	rule, err := tt.ParseRule(args[0])
+	if err != nil {
+		return err
+	}
+
+	value, err := tt.ParseRuleValue(rule, args[1])
+	if err != nil {
+		return err
+	}
+
+	var from time.Time
+	if len(args) == 3 {
+		if from, err = parseDay(args[2]); err != nil {
+			return err
+		}
+	}
+
+	entry, err := app.AddRule(rule, value, from)
+	if err != nil {
+		return err
+	}
+
+	if out.json {
+		enc := json.NewEncoder(out.w)
+		return enc.Encode(entry)
+	}
+
+	fmt.Fprintf(out.w, t("Added rule #%d: %s = %s.\n"), entry.ID, entry.Rule, tt.FormatRuleValue(entry.Rule, entry.Value))
+
+	return nil
+}
+
+func endRule(app *tt.TT, args []string, out output) error {
+	if len(args) < 1 || len(args) > 2 {
+		return tt.InvalidInputError(t("usage: -rules end ID [DATE]"))
+	}
+
+	id, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+
+	at := time.Now()
+	if len(args) == 2 {
+		if at, err = parseDay(args[1]); err != nil {
+			return err
+		}
+	}
+
+	if err := app.EndRule(id, at); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out.w, t("Ended rule #%d on %s.\n"), id, at.Format(dateFormat))
+
+	return nil
+}
+
+func deleteRule(app *tt.TT, args []string, out output) error {
+	if len(args) != 1 {
+		return tt.InvalidInputError(t("usage: -rules rm ID"))
+	}
+
+	id, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := app.DeleteRule(id); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out.w, t("Removed rule #%d.\n"), id)
+
+	return nil
+}
+
+func parseID(str string) (int64, error) {
+	id, err := strconv.ParseInt(str, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, tt.InvalidInputError(fmt.Sprintf("invalid ID: %s", str))
+	}
+
+	return id, nil
+}
+
+func parseDay(str string) (time.Time, error) {
+	day, err := util.ParseTime(str, time.Now())
+	if err != nil {
+		return time.Time{}, tt.InvalidInputError(err.Error())
+	}
+
+	return util.GetStartOfDay(day), nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+	"tt/internal/tt"
+	"tt/internal/util"
+)
+
+// Usage:
+//
+//	tt -schedule
+//	tt -schedule MON TUE WED THU FRI [SAT SUN] [DATE]
+func schedule(app *tt.TT, args []string, out output) error {
+	if len(args) == 0 {
+		return showSchedule(app, out)
+	}
+
+	var (
+		hours [7]float64
+		from  = time.Now()
+	)
+
+	if len(args) == 6 || len(args) == 8 {
+		var err error
+		if from, err = parseDay(args[len(args)-1]); err != nil {
+			return err
+		}
+		args = args[:len(args)-1]
+	}
+
+	if len(args) != 5 && len(args) != 7 {
+		return tt.InvalidInputError(t("usage: -schedule MON TUE WED THU FRI [SAT SUN] [DATE]"))
+	}
+
+	for i, v := range args {
+		var err error
+		if hours[i], err = strconv.ParseFloat(v, 64); err != nil {
+			return tt.InvalidInputError(fmt.Sprintf("invalid hours for %s: %s", weekdayNames[i], v))
+		}
+	}
+
+	if err := app.SetSchedule(hours, from); err != nil {
+		return err
+	}
+
+	return showSchedule(app, out)
+}
+
+func showSchedule(app *tt.TT, out output) error {
+	current, err := app.GetSchedule(time.Now())
+	if err != nil {
+		return err
+	}
+
+	if out.json {
+		enc := json.NewEncoder(out.w)
+		return enc.Encode(current)
+	}
+
+	for i, v := range current {
+		fmt.Fprintf(out.w, "%s %s\n", weekdayNames[i], util.FormatFixedDuration(v))
+	}
+	fmt.Fprintf(out.w, t("Total %s\n"), util.FormatDuration(current.Total()))
+
+	return nil
+}
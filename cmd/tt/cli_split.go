@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"tt/internal/tt"
+	"tt/internal/util"
+)
+
+// Usage:
+//
+//	tt -split [ID] AT [DESC [TAGS]]
+func split(app *tt.TT, args []string, out output) error {
+	if len(args) == 0 {
+		return tt.InvalidInputError(t("usage: -split [ID] AT [DESC [TAGS]]"))
+	}
+
+	var task *tt.Task
+	if id, err := strconv.ParseInt(args[0], 10, 64); err == nil {
+		if task, err = getTaskByID(app, id); err != nil {
+			return err
+		}
+		args = args[1:]
+	}
+
+	if len(args) == 0 {
+		return tt.InvalidInputError(t("usage: -split [ID] AT [DESC [TAGS]]"))
+	}
+
+	base := time.Now()
+	if task != nil {
+		base = task.StartedAt
+	}
+
+	at, err := parseTime(args[0], base)
+	if err != nil {
+		return err
+	}
+
+	if task == nil {
+		if task, err = app.GetTaskAt(at); err != nil {
+			return err
+		}
+	}
+
+	desc, tags := tt.ParseRawDesc(strings.Join(args[1:], " "))
+	first, second, err := app.SplitTask(task.ID, at, desc, tags)
+	if err != nil {
+		return err
+	}
+
+	if out.json {
+		enc := json.NewEncoder(out.w)
+		return enc.Encode([]*tt.Task{first, second})
+	}
+
+	fmt.Fprint(out.w, t("Split the task in:\n"))
+	writeTaskLine(out, *first)
+	writeTaskLine(out, *second)
+
+	return nil
+}
+
+// Usage:
+//
+//	tt -merge TASK TASK...
+//
+// Tasks are given by ID or by a time when they were running.
+func merge(app *tt.TT, args []string, out output) error {
+	if len(args) < 2 {
+		return tt.InvalidInputError(t("usage: -merge TASK TASK..."))
+	}
+
+	ids := make([]int64, 0, len(args))
+	for _, v := range args {
+		task, err := getTaskRef(app, v)
+		if err != nil {
+			return err
+		}
+
+		ids = append(ids, task.ID)
+	}
+
+	task, err := app.MergeTasks(ids)
+	if err != nil {
+		return err
+	}
+
+	if out.json {
+		enc := json.NewEncoder(out.w)
+		return enc.Encode(task)
+	}
+
+	fmt.Fprint(out.w, t("Merged the tasks in:\n"))
+	writeTaskLine(out, *task)
+
+	return nil
+}
+
+// getTaskRef returns the task given by ID or by a time when it was running.
+func getTaskRef(app *tt.TT, str string) (*tt.Task, error) {
+	if id, err := strconv.ParseInt(str, 10, 64); err == nil {
+		return getTaskByID(app, id)
+	}
+
+	at, err := parseTime(str, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return app.GetTaskAt(at)
+}
+
+func getTaskByID(app *tt.TT, id int64) (*tt.Task, error) {
+	task, err := app.GetTask(id)
+	if errors.Is(err, tt.ErrInvalidTaskID) {
+		return nil, tt.InvalidInputError(fmt.Sprintf(t("unknown task ID: %d"), id))
+	}
+
+	return task, err
+}
+
+func writeTaskLine(out output, task tt.Task) {
+	fmt.Fprintf(
+		out.w,
+		"%6d  %s  %6s  %s %s\n",
+		task.ID,
+		formatTaskSpan(task),
+		util.FormatDuration(task.Duration()),
+		task.Description,
+		strings.Join(task.Tags, " "),
+	)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"tt/internal/tt"
+)
+
+// Usage:
+//
+//	tt -push DESC [TAGS]
+func push(app *tt.TT, args []string, at time.Time, out output) error {
+	suspended, next, err := app.Push(strings.Join(args, " "), at)
+	if err != nil {
+		if errors.Is(err, tt.ErrNoCurrentTask) {
+			fmt.Fprint(out.w, t("There is no running task to suspend.\n"))
+			return tt.ExitCodeError(1)
+		}
+
+		return err
+	}
+
+	fmt.Fprintf(out.w, t("Suspended task: \"%s\"\n"), suspended.Description)
+
+	return writeStartMessages(out, nil, next, nil)
+}
+
+// Usage:
+//
+//	tt -pop
+func pop(app *tt.TT, at time.Time, out output) error {
+	prev, next, err := app.Pop(at)
+	if errors.Is(err, tt.ErrEmptyStack) {
+		fmt.Fprint(out.w, t("There is no suspended task.\n"))
+		return tt.ExitCodeError(1)
+	}
+
+	return writeStartMessages(out, prev, next, err)
+}
@@ -17,7 +17,7 @@ import (
 var Version = "unknown"
 
 func main() {
-	if err := run(os.Args[1:], os.Stdout); err != nil {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
 		var inputError tt.InvalidInputError
 		if errors.As(err, &inputError) {
 			fmt.Fprintf(os.Stderr, "error: %s\n", err)
@@ -34,14 +34,14 @@ func main() {
 	}
 }
 
-func run(args []string, out io.Writer) error {
+func run(args []string, in io.Reader, out io.Writer) error {
 	path, err := getDBPath()
 	if err != nil {
 		return err
 	}
 
 	dsn := fmt.Sprintf(`file:%s?mode=rwc`, path)
-	tt, err := tt.New(dsn)
+	tt, err := tt.Open(dsn)
 	if err != nil {
 		return err
 	}
@@ -51,7 +51,7 @@ func run(args []string, out io.Writer) error {
 		}
 	}()
 
-	return dispatch(tt, args, out)
+	return dispatch(tt, args, newPrompt(in, out), out)
 }
 
 var errConfigDir = errors.New(t("unable to fetch config dir"))
@@ -0,0 +1,188 @@
+// nolint:thelper
+package tt_test
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"tt/internal/tt"
+)
+
+// TestConcurrentStart runs many TT instances, each with its own connection
+// pool like separate tt processes, starting and stopping tasks on one file.
+func TestConcurrentStart(t *testing.T) {
+	const (
+		workers    = 8
+		iterations = 25
+	)
+
+	dsn := "file:" + filepath.Join(t.TempDir(), "tt.db") + "?mode=rwc"
+	app, err := tt.New(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer app.Close()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+
+			app, err := tt.New(dsn)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer app.Close()
+
+			for j := 0; j < iterations; j++ {
+				if j%5 == 4 {
+					_, err = app.Stop()
+				} else {
+					_, _, err = app.Start(fmt.Sprintf("task %d-%d", worker, j))
+				}
+
+				// Another process may have started or stopped a task right
+				// after this one read the clock, or stopped the one to stop.
+				if err != nil && !isClockRace(err) && !errors.Is(err, tt.ErrNoCurrentTask) {
+					errs <- err
+					return
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	tasks, err := app.GetTasks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) == 0 {
+		t.Fatal("expected some tasks to be started")
+	}
+
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+
+	var running int
+	for i, v := range tasks {
+		if !v.IsStopped() {
+			running++
+			continue
+		}
+
+		if v.StoppedAt.Before(v.StartedAt) {
+			t.Errorf("task %d stops before it starts: %+v", v.ID, v)
+		}
+		if i+1 < len(tasks) && tasks[i+1].StartedAt.Before(v.StoppedAt) {
+			t.Errorf("task %d overlaps task %d: %+v %+v", v.ID, tasks[i+1].ID, v, tasks[i+1])
+		}
+	}
+
+	if running > 1 {
+		t.Errorf("expected at most one running task, got %d", running)
+	}
+}
+
+// isClockRace returns whether the error is one of the refusals to start or
+// stop a task before the last start or stop, made by another process within
+// the same second.
+func isClockRace(err error) bool {
+	var invalid tt.InvalidInputError
+	if !errors.As(err, &invalid) {
+		return false
+	}
+
+	for _, v := range []string{
+		"the next one cannot start before that",
+		"the next one must start after that",
+		"it must stop after that",
+	} {
+		if strings.Contains(err.Error(), v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TestConcurrentMigrate opens a fresh database from several processes at once,
+// each migration must be applied only once.
+func TestConcurrentMigrate(t *testing.T) {
+	const workers = 4
+
+	dsn := "file:" + filepath.Join(t.TempDir(), "tt.db") + "?mode=rwc"
+
+	var (
+		wg    sync.WaitGroup
+		start = make(chan struct{})
+		errs  = make(chan error, workers)
+	)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+
+			app, err := tt.New(dsn)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer app.Close()
+
+			if _, err := app.GetRules(); err != nil {
+				errs <- err
+			}
+		}()
+	}
+
+	close(start)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	app, err := tt.New(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer app.Close()
+
+	version, steps, err := app.MigrationStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != len(steps) {
+		t.Errorf("expected version %d, got %d", len(steps), version)
+	}
+
+	// The MaxTaskHours seed is a single entry.
+	rules, err := app.GetRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var seeds int
+	for _, v := range rules {
+		if v.Rule == tt.RuleMaxTaskHours {
+			seeds++
+		}
+	}
+	if seeds != 1 {
+		t.Errorf("expected a single MaxTaskHours rule, got %d", seeds)
+	}
+}
@@ -0,0 +1,37 @@
+package tt
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// Keys of the Config table.
+const (
+	configHolidayCalendar = "HolidayCalendar"
+)
+
+// getConfig returns the value stored for the given key, or an empty string if
+// there is none.
+func getConfig(tx *sql.Tx, key string) (string, error) {
+	var value string
+
+	query := `SELECT Value FROM Config WHERE Key = ? LIMIT 1`
+	if err := tx.QueryRow(query, key).Scan(&value); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+
+		return "", BadQueryError{err, query, []interface{}{key}}
+	}
+
+	return value, nil
+}
+
+// setConfig stores a value for the given key, an empty value removes the key.
+func setConfig(tx *sql.Tx, key, value string) error {
+	if value == "" {
+		return exec(tx, `DELETE FROM Config WHERE Key = ?`, key)
+	}
+
+	return exec(tx, `INSERT OR REPLACE INTO Config (Key, Value) VALUES (?, ?)`, key, value)
+}
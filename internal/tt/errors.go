@@ -8,8 +8,14 @@ import (
 var ErrContinue = errors.New("continuing identical running task")
 var ErrNoCurrentTask = errors.New("there is no running task")
 var ErrInvalidTaskID = errors.New("invalid task ID")
+var ErrInvalidRuleID = errors.New("invalid rule ID")
+var ErrInvalidHolidayID = errors.New("invalid holiday ID")
+var ErrInvalidLeaveID = errors.New("invalid leave ID")
 var ErrInvalidTaskDesc = errors.New("invalid task description")
 var ErrNotConfigured = errors.New("missing configuration for this feature")
+var ErrEmptyStack = errors.New("there is no suspended task")
+var ErrNothingToUndo = errors.New("there is nothing to undo")
+var ErrNothingToRedo = errors.New("there is nothing to redo")
 var ErrNoTasks = errors.New("no tasks are present in the specified range")
 
 type IOError struct {
@@ -0,0 +1,149 @@
+package tt
+
+import (
+	"database/sql"
+	"time"
+	"tt/internal/util"
+)
+
+// Internals used by the external tests.
+
+// MigrateTo applies the pending migrations up to the given version, without
+// the backup nor the journal triggers of Migrate.
+func (tt *TT) MigrateTo(version int) error {
+	for v := getCurrentMigrationVersion(tt.db) + 1; v <= version; v++ {
+		if err := tt.transaction(func(tx *sql.Tx) error {
+			return applyMigration(tx, v)
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ExecSQL runs a raw query, eg. to insert rows in an old schema.
+func (tt *TT) ExecSQL(query string, params ...interface{}) error {
+	_, err := tt.writeDB.Exec(query, params...)
+	return err
+}
+
+// QueryInt returns the single integer selected by a raw query.
+func (tt *TT) QueryInt(query string, params ...interface{}) (int, error) {
+	var ret int
+	err := tt.db.QueryRow(query, params...).Scan(&ret)
+
+	return ret, err
+}
+
+// InsertTasks inserts the tasks in a single transaction and sets their ID.
+func (tt *TT) InsertTasks(tasks []Task) error {
+	return tt.transaction(func(tx *sql.Tx) error {
+		for i := range tasks {
+			if err := tasks[i].insert(tx); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetTasksInRange returns the tasks sharing some time with the range.
+func (tt *TT) GetTasksInRange(start, end time.Time) (tasks []Task, err error) {
+	err = tt.readTransaction(func(tx *sql.Tx) error {
+		tasks, err = getTasksInRange(tx, start, end)
+		return err
+	})
+
+	return tasks, err
+}
+
+// ClampTasks bounds the tasks to the range.
+var ClampTasks = clampTasks
+
+// GetOvertimeStrategy returns the built-in strategy with the given name.
+func GetOvertimeStrategy(name string) OvertimeStrategy {
+	for _, v := range overtimeStrategies {
+		if v.name == name {
+			return v.strategy
+		}
+	}
+
+	return nil
+}
+
+// WeekTotals returns the work, target and last counted day of the week.
+var WeekTotals = weekTotals
+
+// GetReportIn returns the report of the given range.
+func (tt *TT) GetReportIn(start, end time.Time) (report Report, err error) {
+	err = tt.readTransaction(func(tx *sql.Tx) error {
+		report, err = tt.getReportTx(tx, start, end)
+		return err
+	})
+
+	return report, err
+}
+
+// GetDurationLeftAt is GetDurationLeft at the given time.
+func (tt *TT) GetDurationLeftAt(now time.Time) (daily, weekly time.Duration, err error) {
+	err = tt.readTransaction(func(tx *sql.Tx) error {
+		daily, weekly, err = tt.getDurationLeft(tx, now)
+		return err
+	})
+
+	return daily, weekly, err
+}
+
+// GetReportPerDay is the report engine querying the tasks of each day, kept
+// as a reference for GetReportIn.
+func (tt *TT) GetReportPerDay(start, end time.Time) (report Report, err error) {
+	err = tt.readTransaction(func(tx *sql.Tx) error {
+		report, err = tt.getReportPerDay(tx, start, end)
+		return err
+	})
+
+	return report, err
+}
+
+func (tt *TT) getReportPerDay(tx *sql.Tx, start, end time.Time) (Report, error) {
+	var (
+		report Report
+		day    = util.GetStartOfDay(start)
+	)
+
+	timeline, err := getRulesTimeline(tx)
+	if err != nil {
+		return Report{}, err
+	}
+
+	off, err := getDaysOff(tx, start, end)
+	if err != nil {
+		return Report{}, err
+	}
+
+	for day.Before(end) {
+		var (
+			rules   = timeline.forDay(day)
+			nextDay = day.AddDate(0, 0, 1)
+		)
+
+		tasks, err := getTasksInRange(tx, day, nextDay)
+		if err != nil {
+			return Report{}, err
+		}
+
+		entry := newReportEntry(day, clampTasks(tasks, day, nextDay), rules, off)
+		applyMissingDayPolicy(&entry, rules, nextDay.After(end))
+		rules.overtimeStrategy().Day(&entry)
+		report.Daily = append(report.Daily, entry)
+
+		day = nextDay
+	}
+
+	applyWeeklyStrategies(report.Daily, timeline)
+	report.computeAggregates()
+
+	return report, nil
+}
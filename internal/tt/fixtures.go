@@ -14,7 +14,7 @@ func (tt *TT) Fixture() error {
 	words := loadTags()
 
 	return tt.transaction(func(tx *sql.Tx) error {
-		tasks, err := tt.GetTasks()
+		tasks, err := getAllTasks(tx)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,162 @@
+package tt
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"tt/internal/util"
+)
+
+// ForgottenTask is a task that lasted longer than the MaxTaskHours rule allows,
+// most likely because it was not stopped in time.
+type ForgottenTask struct {
+	Task
+	SuggestedStop time.Time // where the task should be truncated
+}
+
+// forgottenStop returns where a forgotten task should be truncated: at the
+// EndOfDayHour of its start day if it is set and between the task start and
+// MaxTaskHours, or after MaxTaskHours otherwise. On call tasks legitimately
+// last for days, they are never forgotten.
+func forgottenStop(task Task, rules rulesSnapshot, now time.Time) (time.Time, bool) {
+	max := hoursToDuration(rules[RuleMaxTaskHours])
+	if max <= 0 || task.HasTag(tagOnCall) {
+		return time.Time{}, false
+	}
+
+	end := task.StoppedAt
+	if end.IsZero() {
+		end = now
+	}
+
+	if end.Sub(task.StartedAt) <= max {
+		return time.Time{}, false
+	}
+
+	stop := task.StartedAt.Add(max)
+	if eod := rules[RuleEndOfDayHour]; eod > 0 {
+		v := util.GetStartOfDay(task.StartedAt).Add(hoursToDuration(eod))
+		if v.After(task.StartedAt) && v.Before(stop) {
+			stop = v
+		}
+	}
+
+	return stop, true
+}
+
+func getForgottenTasks(tx *sql.Tx, now time.Time) ([]ForgottenTask, error) {
+	timeline, err := getRulesTimeline(tx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch rules: %w", err)
+	}
+
+	// Only fetch tasks longer than the smallest maximum in the timeline.
+	var min float64
+	for _, v := range timeline {
+		if v.Rule == RuleMaxTaskHours && v.Value > 0 && (min == 0 || v.Value < min) {
+			min = v.Value
+		}
+	}
+	if min == 0 {
+		return nil, nil
+	}
+
+	tasks, err := queryTasks(tx, fmt.Sprintf(
+		`SELECT %s FROM Task
+        WHERE IFNULL(StoppedAt, ?) - StartedAt > ?
+        ORDER BY StartedAt ASC`,
+		taskProxyFields(),
+	), now.Unix(), int64(hoursToDuration(min).Seconds()))
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []ForgottenTask
+	for _, v := range tasks {
+		if stop, ok := forgottenStop(v, timeline.forDay(v.StartedAt), now); ok {
+			ret = append(ret, ForgottenTask{Task: v, SuggestedStop: stop})
+		}
+	}
+
+	return ret, nil
+}
+
+// GetForgottenTasks returns all the tasks, stopped or not, that lasted longer
+// than the MaxTaskHours rule allows.
+func (tt *TT) GetForgottenTasks() ([]ForgottenTask, error) {
+	var ret []ForgottenTask
+
+	if err := tt.readTransaction(func(tx *sql.Tx) (err error) {
+		ret, err = getForgottenTasks(tx, time.Now())
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// GetForgottenCurrentTask returns the running task if it has been running for
+// longer than the MaxTaskHours rule allows, or nil.
+func (tt *TT) GetForgottenCurrentTask() (*ForgottenTask, error) {
+	var ret *ForgottenTask
+
+	if err := tt.readTransaction(func(tx *sql.Tx) error {
+		current, err := getCurrentTask(tx)
+		if err != nil {
+			if errors.Is(err, ErrNoCurrentTask) {
+				return nil
+			}
+			return err
+		}
+
+		timeline, err := getRulesTimeline(tx)
+		if err != nil {
+			return fmt.Errorf("unable to fetch rules: %w", err)
+		}
+
+		if stop, ok := forgottenStop(*current, timeline.forDay(current.StartedAt), time.Now()); ok {
+			ret = &ForgottenTask{Task: *current, SuggestedStop: stop}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// FixForgottenTask truncates the given task so it stops at the given time.
+func (tt *TT) FixForgottenTask(id int64, stop time.Time) (*Task, error) {
+	var task *Task
+
+	if err := tt.transaction(func(tx *sql.Tx) (err error) {
+		task, err = getTask(tx, id)
+		if err != nil {
+			return err
+		}
+
+		if !stop.After(task.StartedAt) {
+			return InvalidInputError(fmt.Sprintf(
+				"the task started at %s, it must stop after that",
+				task.StartedAt.Format(timeFormat),
+			))
+		}
+
+		if task.IsStopped() && stop.After(task.StoppedAt) {
+			return InvalidInputError(fmt.Sprintf(
+				"the task stopped at %s, it can only be shortened",
+				task.StoppedAt.Format(timeFormat),
+			))
+		}
+
+		task.StoppedAt = stop
+		return task.update(tx)
+	}); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
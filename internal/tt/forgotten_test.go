@@ -0,0 +1,87 @@
+// nolint:thelper
+package tt_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"tt/internal/tt"
+)
+
+func TestForgottenTasks(t *testing.T) {
+	app := newTestApp(t)
+	monday := time.Date(2024, 3, 4, 0, 0, 0, 0, time.Local)
+
+	addTestTasks(
+		t, app, monday,
+		testTask{"short", 9 * time.Hour, 17 * time.Hour},
+		testTask{"long", 24*time.Hour + 9*time.Hour, 24*time.Hour + 23*time.Hour},
+		testTask{"night", 48*time.Hour + 20*time.Hour, 72*time.Hour + 10*time.Hour},
+		testTask{"weekend @oncall", 96*time.Hour + 18*time.Hour, 168 * time.Hour},
+	)
+
+	if _, _, err := app.StartAt("running", time.Now().Add(-13*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := app.AddRule(tt.RuleEndOfDayHour, 18.5, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	current, err := app.GetForgottenCurrentTask()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current == nil || current.Description != "running" {
+		t.Fatalf("expected the running task to be forgotten, got %+v", current)
+	}
+
+	tasks, err := app.GetForgottenTasks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 3 {
+		t.Fatalf("expected 3 forgotten tasks, got %+v", tasks)
+	}
+
+	// Truncated at the end of the day, or after 12h when started after it.
+	if expected := monday.Add(24*time.Hour + 18*time.Hour + 30*time.Minute); !tasks[0].SuggestedStop.Equal(expected) {
+		t.Errorf("expected %s, got %s", expected, tasks[0].SuggestedStop)
+	}
+	if expected := monday.Add(48*time.Hour + 32*time.Hour); !tasks[1].SuggestedStop.Equal(expected) {
+		t.Errorf("expected %s, got %s", expected, tasks[1].SuggestedStop)
+	}
+
+	if _, err := app.FixForgottenTask(tasks[0].ID, tasks[0].StoppedAt.Add(time.Hour)); !isInvalidInput(err) {
+		t.Errorf("expected InvalidInputError when lengthening a task, got %v", err)
+	}
+	if _, err := app.FixForgottenTask(tasks[0].ID, tasks[0].StartedAt); !isInvalidInput(err) {
+		t.Errorf("expected InvalidInputError when stopping before the start, got %v", err)
+	}
+
+	for _, v := range tasks {
+		if _, err := app.FixForgottenTask(v.ID, v.SuggestedStop); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if tasks, err := app.GetForgottenTasks(); err != nil || len(tasks) != 0 {
+		t.Errorf("expected no forgotten tasks left, got %v %v", tasks, err)
+	}
+	if _, err := app.CurrentTask(); !errors.Is(err, tt.ErrNoCurrentTask) {
+		t.Errorf("expected the running task to be stopped, got %v", err)
+	}
+
+	// The user chose the fix, it can be undone.
+	if _, err := app.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if tasks, err := app.GetForgottenTasks(); err != nil || len(tasks) != 1 {
+		t.Errorf("expected the last fixed task to be forgotten again, got %v %v", tasks, err)
+	}
+}
+
+func isInvalidInput(err error) bool {
+	var inputError tt.InvalidInputError
+	return errors.As(err, &inputError)
+}
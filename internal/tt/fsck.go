@@ -0,0 +1,355 @@
+package tt
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Kinds of anomalies found by Fsck.
+const (
+	AnomalyMultipleRunning = "multiple-running"
+	AnomalyNegativeTask    = "negative-duration"
+	AnomalyEmptyTask       = "empty-task"
+	AnomalyOverlap         = "overlap"
+	AnomalyOrphanTaskTag   = "orphan-task-tag"
+	AnomalyUnusedTag       = "unused-tag"
+	AnomalyOrphanStack     = "orphan-stack"
+)
+
+// Anomaly is an inconsistency in the database.
+type Anomaly struct {
+	Kind     string
+	Message  string
+	TaskIDs  []int64 `json:",omitempty"`
+	Repair   string  // how it is repaired, empty if it needs a manual fix
+	Repaired bool
+
+	fix func(tx *sql.Tx) error
+}
+
+// Fsck checks the database for anomalies and repairs those that can be safely
+// repaired if repair is true, all repairs are made in a single transaction.
+func (tt *TT) Fsck(repair bool) ([]Anomaly, error) {
+	var ret []Anomaly
+
+	err := tt.transaction(func(tx *sql.Tx) error {
+		for _, check := range []func(tx *sql.Tx) ([]Anomaly, error){
+			checkRunningTasks,
+			checkTaskDurations,
+			checkOverlaps,
+			checkTags,
+			checkStack,
+		} {
+			anomalies, err := check(tx)
+			if err != nil {
+				return err
+			}
+
+			for i, v := range anomalies {
+				if !repair || v.fix == nil {
+					continue
+				}
+
+				if err := v.fix(tx); err != nil {
+					return err
+				}
+				anomalies[i].Repaired = true
+			}
+
+			ret = append(ret, anomalies...)
+		}
+
+		return nil
+	})
+
+	return ret, err
+}
+
+// fsckTask is the part of a task that can be read whatever its state.
+type fsckTask struct {
+	ID          int64
+	Description string
+	StartedAt   time.Time
+	StoppedAt   time.Time
+}
+
+func (t fsckTask) String() string {
+	stop := "now"
+	if !t.StoppedAt.IsZero() {
+		stop = t.StoppedAt.Format(timeFormat)
+	}
+
+	return fmt.Sprintf("#%d \"%s\" (%s to %s)", t.ID, t.Description, t.StartedAt.Format(timeFormat), stop)
+}
+
+func queryFsckTasks(tx *sql.Tx, where string, params ...interface{}) ([]fsckTask, error) {
+	query := `SELECT ID, Description, StartedAt, StoppedAt FROM Task ` + where
+
+	rows, err := tx.Query(query, params...)
+	if err != nil {
+		return nil, BadQueryError{err, query, params}
+	}
+	defer rows.Close()
+
+	var ret []fsckTask
+	for rows.Next() {
+		var (
+			v         fsckTask
+			startedAt int64
+			stoppedAt sql.NullInt64
+		)
+
+		if err := rows.Scan(&v.ID, &v.Description, &startedAt, &stoppedAt); err != nil {
+			return nil, BadQueryError{err, query, params}
+		}
+
+		v.StartedAt = time.Unix(startedAt, 0)
+		if stoppedAt.Valid {
+			v.StoppedAt = time.Unix(stoppedAt.Int64, 0)
+		}
+		ret = append(ret, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, BadQueryError{err, query, params}
+	}
+
+	return ret, nil
+}
+
+// checkRunningTasks finds running tasks other than the last one, they are
+// stopped when the next task started.
+func checkRunningTasks(tx *sql.Tx) ([]Anomaly, error) {
+	running, err := queryFsckTasks(tx, `WHERE StoppedAt IS NULL ORDER BY StartedAt ASC, ID ASC`)
+	if err != nil || len(running) < 2 {
+		return nil, err
+	}
+
+	var ret []Anomaly
+	for _, v := range running[:len(running)-1] {
+		v := v
+
+		next, err := queryFsckTasks(
+			tx,
+			`WHERE ID != ? AND StartedAt >= ? ORDER BY StartedAt ASC, ID ASC LIMIT 1`,
+			v.ID, v.StartedAt.Unix(),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		anomaly := Anomaly{
+			Kind:    AnomalyMultipleRunning,
+			Message: fmt.Sprintf("task %s is running along with a later task", v),
+			TaskIDs: []int64{v.ID},
+		}
+
+		if stop := next[0].StartedAt; stop.After(v.StartedAt) {
+			anomaly.Repair = fmt.Sprintf("stop it at %s", stop.Format(timeFormat))
+			anomaly.fix = func(tx *sql.Tx) error {
+				return stopTask(tx, v.ID, stop)
+			}
+		}
+
+		ret = append(ret, anomaly)
+	}
+
+	return ret, nil
+}
+
+// checkTaskDurations finds tasks ending before they start, which are most
+// likely inverted, and empty ones, which are deleted.
+func checkTaskDurations(tx *sql.Tx) ([]Anomaly, error) {
+	tasks, err := queryFsckTasks(tx, `WHERE StoppedAt <= StartedAt OR Description = '' ORDER BY StartedAt ASC`)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []Anomaly
+	for _, v := range tasks {
+		v := v
+
+		switch {
+		case v.StoppedAt.Equal(v.StartedAt):
+			ret = append(ret, Anomaly{
+				Kind:    AnomalyEmptyTask,
+				Message: fmt.Sprintf("task %s lasts 0s", v),
+				TaskIDs: []int64{v.ID},
+				Repair:  "delete it",
+				fix: func(tx *sql.Tx) error {
+					return deleteTask(tx, v.ID)
+				},
+			})
+		case !v.StoppedAt.IsZero() && v.StoppedAt.Before(v.StartedAt):
+			ret = append(ret, Anomaly{
+				Kind:    AnomalyNegativeTask,
+				Message: fmt.Sprintf("task %s ends before it starts", v),
+				TaskIDs: []int64{v.ID},
+				Repair:  "swap its start and stop times",
+				fix: func(tx *sql.Tx) error {
+					if err := exec(
+						tx,
+						`UPDATE Task SET StartedAt = StoppedAt, StoppedAt = StartedAt WHERE ID = ?`,
+						v.ID,
+					); err != nil {
+						return err
+					}
+
+					return addTaskHistory(tx, v.ID, historyUpdate)
+				},
+			})
+		default:
+			ret = append(ret, Anomaly{
+				Kind:    AnomalyEmptyTask,
+				Message: fmt.Sprintf("task %s has no description", v),
+				TaskIDs: []int64{v.ID},
+			})
+		}
+	}
+
+	return ret, nil
+}
+
+// checkOverlaps finds overlapping tasks, a task partly overlapped by the next
+// one is stopped when the next one starts. Tasks starting together or
+// containing another one need a manual fix.
+func checkOverlaps(tx *sql.Tx) ([]Anomaly, error) {
+	now := time.Now().Unix()
+	query := `SELECT a.ID, b.ID FROM Task AS a
+        JOIN Task AS b ON a.ID != b.ID
+            AND (a.StartedAt < b.StartedAt OR (a.StartedAt = b.StartedAt AND a.ID < b.ID))
+            AND b.StartedAt < IFNULL(a.StoppedAt, ?)
+        WHERE IFNULL(a.StoppedAt, ?) > a.StartedAt
+            AND IFNULL(b.StoppedAt, ?) > b.StartedAt
+            AND NOT (a.StoppedAt IS NULL AND b.StoppedAt IS NULL)
+        ORDER BY a.StartedAt ASC, b.StartedAt ASC`
+
+	rows, err := tx.Query(query, now, now, now)
+	if err != nil {
+		return nil, BadQueryError{err, query, nil}
+	}
+
+	var pairs [][2]int64
+	for rows.Next() {
+		var pair [2]int64
+		if err := rows.Scan(&pair[0], &pair[1]); err != nil {
+			rows.Close()
+			return nil, BadQueryError{err, query, nil}
+		}
+		pairs = append(pairs, pair)
+	}
+	rows.Close()
+
+	var ret []Anomaly
+	for _, pair := range pairs {
+		tasks, err := queryFsckTasks(tx, `WHERE ID IN (?, ?) ORDER BY StartedAt ASC, ID ASC`, pair[0], pair[1])
+		if err != nil {
+			return nil, err
+		}
+		first, second := tasks[0], tasks[1]
+
+		anomaly := Anomaly{
+			Kind:    AnomalyOverlap,
+			Message: fmt.Sprintf("task %s overlaps task %s", first, second),
+			TaskIDs: []int64{first.ID, second.ID},
+		}
+
+		contained := !first.StoppedAt.IsZero() && !second.StoppedAt.IsZero() && second.StoppedAt.Before(first.StoppedAt)
+		if second.StartedAt.After(first.StartedAt) && !contained {
+			anomaly.Repair = fmt.Sprintf("stop the first one at %s", second.StartedAt.Format(timeFormat))
+			anomaly.fix = func(tx *sql.Tx) error {
+				// an earlier repair might have stopped it before already
+				stop := second.StartedAt.Unix()
+				affected, err := execWithRowsAffected(
+					tx,
+					`UPDATE Task SET StoppedAt = ? WHERE ID = ? AND IFNULL(StoppedAt, ?) > ?`,
+					stop, first.ID, now, stop,
+				)
+				if err != nil || affected == 0 {
+					return err
+				}
+
+				return addTaskHistory(tx, first.ID, historyUpdate)
+			}
+		}
+
+		ret = append(ret, anomaly)
+	}
+
+	return ret, nil
+}
+
+// checkTags finds tags of deleted tasks, missing tags and tags not used by any
+// task, they are all deleted.
+func checkTags(tx *sql.Tx) ([]Anomaly, error) {
+	var ret []Anomaly
+
+	for _, v := range []struct {
+		kind, message, count, fix string
+	}{
+		{
+			AnomalyOrphanTaskTag,
+			"%d task tags refer to a missing task or tag",
+			`SELECT COUNT(*) FROM TaskTag
+            WHERE TaskID NOT IN (SELECT ID FROM Task) OR TagID NOT IN (SELECT ID FROM Tag)`,
+			`DELETE FROM TaskTag
+            WHERE TaskID NOT IN (SELECT ID FROM Task) OR TagID NOT IN (SELECT ID FROM Tag)`,
+		},
+		{
+			AnomalyUnusedTag,
+			"%d tags are not used by any task",
+			`SELECT COUNT(*) FROM Tag WHERE ID NOT IN (SELECT TagID FROM TaskTag WHERE TaskID IN (SELECT ID FROM Task))`,
+			`DELETE FROM Tag WHERE ID NOT IN (SELECT TagID FROM TaskTag WHERE TaskID IN (SELECT ID FROM Task))`,
+		},
+	} {
+		anomaly, err := countAnomaly(tx, v.kind, v.message, v.count, v.fix)
+		if err != nil {
+			return nil, err
+		}
+
+		if anomaly != nil {
+			ret = append(ret, *anomaly)
+		}
+	}
+
+	return ret, nil
+}
+
+// checkStack finds suspended tasks that do not exist anymore.
+func checkStack(tx *sql.Tx) ([]Anomaly, error) {
+	anomaly, err := countAnomaly(
+		tx,
+		AnomalyOrphanStack,
+		"%d suspended tasks do not exist anymore",
+		`SELECT COUNT(*) FROM TaskStack WHERE TaskID NOT IN (SELECT ID FROM Task)`,
+		`DELETE FROM TaskStack WHERE TaskID NOT IN (SELECT ID FROM Task)`,
+	)
+	if err != nil || anomaly == nil {
+		return nil, err
+	}
+
+	return []Anomaly{*anomaly}, nil
+}
+
+// countAnomaly returns an anomaly repaired by deleting rows if the count query
+// returns a positive number.
+func countAnomaly(tx *sql.Tx, kind, message, count, fix string) (*Anomaly, error) {
+	var n int
+	if err := tx.QueryRow(count).Scan(&n); err != nil {
+		return nil, BadQueryError{err, count, nil}
+	}
+
+	if n == 0 {
+		return nil, nil
+	}
+
+	return &Anomaly{
+		Kind:    kind,
+		Message: fmt.Sprintf(message, n),
+		Repair:  "delete them",
+		fix: func(tx *sql.Tx) error {
+			return exec(tx, fix)
+		},
+	}, nil
+}
@@ -0,0 +1,104 @@
+// nolint:thelper
+package tt_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+	"tt/internal/tt"
+)
+
+func TestFsck(t *testing.T) {
+	app := newTestApp(t)
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.Local)
+	at := func(hour float64) int64 { return day.Add(time.Duration(hour * float64(time.Hour))).Unix() }
+
+	// Databases created before the running task index may hold several.
+	if err := app.ExecSQL(`DROP INDEX Task_Running`); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range []struct {
+		id          int64
+		desc        string
+		start, stop float64 // a zero stop means running
+	}{
+		{1, "ok", 8, 9},
+		{2, "inverted", 10, 9.5},
+		{3, "long", 11, 13}, // partly overlapped by 4
+		{4, "meeting", 12, 14},
+		{5, "outer", 15, 18}, // contains 6
+		{6, "inner", 16, 17},
+		{7, "forgotten", 19, 0},
+		{8, "running", 20, 0},
+	} {
+		stop := sql.NullInt64{Int64: at(v.stop), Valid: v.stop != 0}
+		if err := app.ExecSQL(
+			`INSERT INTO Task (ID, Description, StartedAt, StoppedAt) VALUES (?, ?, ?, ?)`,
+			v.id, v.desc, at(v.start), stop,
+		); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, v := range []string{
+		`INSERT INTO Tag (ID, Name) VALUES (1, '@unused')`,
+		`INSERT INTO TaskTag (TaskID, TagID, Position) VALUES (42, 1, 0)`,
+		`INSERT INTO TaskStack (TaskID) VALUES (42)`,
+	} {
+		if err := app.ExecSQL(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	anomalies, err := app.Fsck(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		tt.AnomalyMultipleRunning,
+		tt.AnomalyNegativeTask,
+		tt.AnomalyOverlap,
+		tt.AnomalyOverlap,
+		tt.AnomalyOrphanTaskTag,
+		tt.AnomalyUnusedTag,
+		tt.AnomalyOrphanStack,
+	}
+	if len(anomalies) != len(expected) {
+		t.Fatalf("expected %d anomalies, got %+v", len(expected), anomalies)
+	}
+	for i, v := range expected {
+		if anomalies[i].Kind != v || anomalies[i].Repaired {
+			t.Errorf("anomaly %d: expected %s, got %+v", i, v, anomalies[i])
+		}
+	}
+
+	if anomalies, err = app.Fsck(true); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range anomalies {
+		if v.Repaired == (v.Repair == "") {
+			t.Errorf("expected only repairable anomalies to be repaired, got %+v", v)
+		}
+	}
+
+	// Only the contained task is left.
+	if anomalies, err = app.Fsck(false); err != nil {
+		t.Fatal(err)
+	}
+	if len(anomalies) != 1 || anomalies[0].Kind != tt.AnomalyOverlap || anomalies[0].TaskIDs[1] != 6 {
+		t.Errorf("unexpected anomalies left: %+v", anomalies)
+	}
+
+	for id, times := range map[int64][2]float64{2: {9.5, 10}, 3: {11, 12}, 7: {19, 20}} {
+		task, err := app.GetTask(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if task.StartedAt.Unix() != at(times[0]) || task.StoppedAt.Unix() != at(times[1]) {
+			t.Errorf("task %d: unexpected repair: %+v", id, task)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package tt
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"tt/internal/util"
+)
+
+// Shorter gaps are ignored, they are most likely the time taken to type the
+// next command.
+const minGapDuration = time.Minute
+
+const configBreaks = "Breaks"
+
+// Break is a daily interval that is not expected to be tracked, eg. lunch.
+type Break struct {
+	Start, End time.Duration // since midnight
+}
+
+// ParseBreak parses a break such as "12:00-13:00" or "12h-13h30".
+func ParseBreak(str string) (Break, error) {
+	parts := strings.Split(str, "-")
+	if len(parts) != 2 {
+		return Break{}, InvalidInputError(fmt.Sprintf("invalid break \"%s\", expected eg. 12:00-13:00", str))
+	}
+
+	var (
+		ret   Break
+		day   = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+		times = []*time.Duration{&ret.Start, &ret.End}
+	)
+
+	for i, v := range parts {
+		t, err := util.ParseTimeOn(v, day, day)
+		if err != nil {
+			return Break{}, InvalidInputError(err.Error())
+		}
+
+		*times[i] = t.Sub(day)
+	}
+
+	if ret.Start < 0 || ret.End > 24*time.Hour {
+		return Break{}, InvalidInputError(fmt.Sprintf("invalid break \"%s\", it must be within a day", str))
+	}
+	if ret.End <= ret.Start {
+		return Break{}, InvalidInputError(fmt.Sprintf("invalid break \"%s\", it must end after it starts", str))
+	}
+
+	return ret, nil
+}
+
+func (b Break) String() string {
+	format := func(d time.Duration) string {
+		return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+	}
+
+	return format(b.Start) + "-" + format(b.End)
+}
+
+// on returns the break on the given day.
+func (b Break) on(day time.Time) (time.Time, time.Time) {
+	y, m, d := day.Date()
+
+	return time.Date(y, m, d, 0, int(b.Start/time.Minute), 0, 0, day.Location()),
+		time.Date(y, m, d, 0, int(b.End/time.Minute), 0, 0, day.Location())
+}
+
+// GetBreaks returns the configured daily breaks, sorted.
+func (tt *TT) GetBreaks() ([]Break, error) {
+	var ret []Break
+
+	err := tt.readTransaction(func(tx *sql.Tx) (err error) {
+		ret, err = getBreaks(tx)
+		return err
+	})
+
+	return ret, err
+}
+
+// SetBreaks replaces the daily breaks, an empty list removes them.
+func (tt *TT) SetBreaks(breaks []Break) error {
+	values := make([]string, 0, len(breaks))
+	for _, v := range breaks {
+		values = append(values, v.String())
+	}
+
+	return tt.transaction(func(tx *sql.Tx) error {
+		return setConfig(tx, configBreaks, strings.Join(values, " "))
+	})
+}
+
+func getBreaks(tx *sql.Tx) ([]Break, error) {
+	value, err := getConfig(tx, configBreaks)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []Break
+	for _, v := range strings.Fields(value) {
+		b, err := ParseBreak(v)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, b)
+	}
+
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Start < ret[j].Start })
+
+	return ret, nil
+}
+
+// Gap is an untracked interval between two tasks of the same day.
+type Gap struct {
+	Start, End time.Time
+	Previous   *Task // the task stopping at the start of the gap, if any
+	Next       *Task // the task starting at the end of the gap, if any
+}
+
+func (g Gap) Duration() time.Duration {
+	return g.End.Sub(g.Start)
+}
+
+// GetGaps returns the gaps between the first and the last task of every day
+// from start to end, breaks excluded.
+func (tt *TT) GetGaps(start, end time.Time) ([]Gap, error) {
+	var ret []Gap
+
+	err := tt.readTransaction(func(tx *sql.Tx) error {
+		breaks, err := getBreaks(tx)
+		if err != nil {
+			return err
+		}
+
+		for day := util.GetStartOfDay(start); day.Before(end); day = day.AddDate(0, 0, 1) {
+			tasks, err := getTasksInRange(tx, day, day.AddDate(0, 0, 1))
+			if err != nil {
+				return err
+			}
+
+			ret = append(ret, getDayGaps(tasks, day, breaks)...)
+		}
+
+		return nil
+	})
+
+	return ret, err
+}
+
+// getDayGaps returns the gaps between the given tasks, sorted by start time,
+// on the given day.
+func getDayGaps(tasks []Task, day time.Time, breaks []Break) []Gap {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	var (
+		ret      []Gap
+		dayEnd   = day.AddDate(0, 0, 1)
+		previous = tasks[0]
+		covered  = tasks[0].end()
+	)
+
+	for _, v := range tasks[1:] {
+		if v.StartedAt.After(covered) && covered.Before(dayEnd) {
+			prev, next := previous, v
+			ret = append(ret, splitGap(Gap{covered, v.StartedAt, &prev, &next}, day, breaks)...)
+		}
+
+		if v.end().After(covered) {
+			previous, covered = v, v.end()
+		}
+	}
+
+	return ret
+}
+
+// splitGap removes the breaks from the gap, neighbours are only kept on the
+// parts that touch them.
+func splitGap(gap Gap, day time.Time, breaks []Break) []Gap {
+	var ret []Gap
+
+	for _, v := range breaks {
+		start, end := v.on(day)
+		if !start.Before(gap.End) || !end.After(gap.Start) {
+			continue
+		}
+
+		if start.After(gap.Start) {
+			ret = append(ret, Gap{gap.Start, start, gap.Previous, nil})
+		}
+
+		gap.Start, gap.Previous = end, nil
+		if !gap.Start.Before(gap.End) {
+			break
+		}
+	}
+
+	if gap.Start.Before(gap.End) {
+		ret = append(ret, gap)
+	}
+
+	filtered := ret[:0]
+	for _, v := range ret {
+		if v.Duration() >= minGapDuration {
+			filtered = append(filtered, v)
+		}
+	}
+
+	return filtered
+}
+
+// ExtendOverGap stretches the task right before the gap, or right after it if
+// previous is false, to cover the gap.
+func (tt *TT) ExtendOverGap(gap Gap, previous bool) (*Task, error) {
+	neighbour := gap.Next
+	if previous {
+		neighbour = gap.Previous
+	}
+
+	if neighbour == nil {
+		return nil, InvalidInputError("there is no task next to this gap")
+	}
+
+	var task *Task
+	err := tt.transaction(func(tx *sql.Tx) (err error) {
+		task, err = getTask(tx, neighbour.ID)
+		if err != nil {
+			return err
+		}
+
+		if previous {
+			task.StoppedAt = gap.End
+		} else {
+			task.StartedAt = gap.Start
+		}
+
+		return task.update(tx)
+	})
+
+	return task, err
+}
+
+// AssignGap records the gap as a new task with the description and tags of the
+// given one.
+func (tt *TT) AssignGap(gap Gap, taskID int64) (*Task, error) {
+	var task *Task
+
+	err := tt.transaction(func(tx *sql.Tx) error {
+		source, err := getTask(tx, taskID)
+		if err != nil {
+			return err
+		}
+
+		task = &Task{
+			Description: source.Description,
+			Tags:        source.Tags,
+			StartedAt:   gap.Start,
+			StoppedAt:   gap.End,
+		}
+
+		return task.insert(tx)
+	})
+
+	return task, err
+}
@@ -0,0 +1,99 @@
+// nolint:thelper
+package tt_test
+
+import (
+	"testing"
+	"time"
+	"tt/internal/tt"
+)
+
+func TestGaps(t *testing.T) {
+	app := newTestApp(t)
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.Local)
+
+	addTestTasks(
+		t, app, day,
+		testTask{"first", 9 * time.Hour, 10 * time.Hour},
+		testTask{"second", 10*time.Hour + 30*time.Second, 11 * time.Hour},
+		testTask{"third", 14 * time.Hour, 15 * time.Hour},
+		testTask{"fourth", 16 * time.Hour, 17 * time.Hour},
+	)
+
+	lunch, err := tt.ParseBreak("12h-13h30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := app.SetBreaks([]tt.Break{lunch}); err != nil {
+		t.Fatal(err)
+	}
+
+	gaps, err := app.GetGaps(day, day.AddDate(0, 0, 7))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The 30s gap is ignored, the lunch break is removed from the 11h-14h one.
+	expected := [][2]time.Duration{
+		{11 * time.Hour, 12 * time.Hour},
+		{13*time.Hour + 30*time.Minute, 14 * time.Hour},
+		{15 * time.Hour, 16 * time.Hour},
+	}
+	if len(gaps) != len(expected) {
+		t.Fatalf("expected %d gaps, got %+v", len(expected), gaps)
+	}
+	for i, v := range expected {
+		if !gaps[i].Start.Equal(day.Add(v[0])) || !gaps[i].End.Equal(day.Add(v[1])) {
+			t.Errorf("expected gap %d from %s to %s, got %+v", i, v[0], v[1], gaps[i])
+		}
+	}
+
+	if gaps[0].Previous == nil || gaps[0].Previous.Description != "second" || gaps[0].Next != nil {
+		t.Errorf("expected the first part to touch only the previous task, got %+v", gaps[0])
+	}
+	if gaps[1].Previous != nil || gaps[1].Next == nil || gaps[1].Next.Description != "third" {
+		t.Errorf("expected the second part to touch only the next task, got %+v", gaps[1])
+	}
+
+	if _, err := app.ExtendOverGap(gaps[1], true); !isInvalidInput(err) {
+		t.Errorf("expected an invalid input error, got %v", err)
+	}
+	if _, err := app.ExtendOverGap(gaps[0], true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.AssignGap(gaps[2], gaps[0].Previous.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if gaps, err = app.GetGaps(day, day.AddDate(0, 0, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if len(gaps) != 1 {
+		t.Fatalf("expected 1 gap left, got %+v", gaps)
+	}
+}
+
+func TestParseBreak(t *testing.T) {
+	for _, v := range []struct {
+		input, expected string
+	}{
+		{"12:00-13:00", "12:00-13:00"},
+		{"12h-13h30", "12:00-13:30"},
+		{"noon-1pm", "12:00-13:00"},
+		{"13:00-12:00", ""},
+		{"12:00", ""},
+		{"yesterday 12:00-13:00", ""},
+		{"12:00-tomorrow 1:00", ""},
+	} {
+		b, err := tt.ParseBreak(v.input)
+		if v.expected == "" {
+			if !isInvalidInput(err) {
+				t.Errorf("%s: expected an invalid input error, got %v", v.input, err)
+			}
+			continue
+		}
+
+		if err != nil || b.String() != v.expected {
+			t.Errorf("%s: expected %s, got %s (%v)", v.input, v.expected, b, err)
+		}
+	}
+}
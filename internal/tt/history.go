@@ -0,0 +1,191 @@
+package tt
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+	"tt/internal/util"
+)
+
+// Kinds of task history entries.
+const (
+	historyInsert = "insert"
+	historyUpdate = "update"
+	historyDelete = "delete"
+)
+
+// TaskHistoryEntry is the state of a task after a change, or before its
+// deletion.
+type TaskHistoryEntry struct {
+	ID        int64
+	ChangedAt time.Time
+	Source    string // see SetSource
+	Kind      string // insert, update or delete
+	Task      Task
+}
+
+// GetTaskHistory returns the changes made to a task, the oldest first.
+func (tt *TT) GetTaskHistory(taskID int64) ([]TaskHistoryEntry, error) {
+	var ret []TaskHistoryEntry
+
+	err := tt.readTransaction(func(tx *sql.Tx) error {
+		query := `SELECT ID, ChangedAt, IFNULL(Source, ''), Kind,
+                TaskID, Description, StartedAt, StoppedAt, Deadline, Tags
+            FROM TaskHistory WHERE TaskID = ? ORDER BY ID ASC`
+
+		rows, err := tx.Query(query, taskID)
+		if err != nil {
+			return BadQueryError{err, query, []interface{}{taskID}}
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				entry     TaskHistoryEntry
+				changedAt util.TimeAsTimestamp
+				proxy     taskProxy
+			)
+
+			if err := rows.Scan(
+				&entry.ID, &changedAt, &entry.Source, &entry.Kind,
+				&proxy.ID, &proxy.Description, &proxy.StartedAt, &proxy.StoppedAt, &proxy.Deadline, &proxy.Tags,
+			); err != nil {
+				return BadQueryError{err, query, []interface{}{taskID}}
+			}
+
+			task, err := proxy.Task()
+			if err != nil {
+				return err
+			}
+
+			entry.ChangedAt, entry.Task = changedAt.Time(), *task
+			ret = append(ret, entry)
+		}
+
+		if err := rows.Err(); err != nil {
+			return BadQueryError{err, query, []interface{}{taskID}}
+		}
+
+		if len(ret) == 0 {
+			return ErrInvalidTaskID
+		}
+
+		return nil
+	})
+
+	return ret, err
+}
+
+// setHistorySource sets the source of the history entries added by the rest of
+// the transaction. It is kept in a temporary table, private to the connection
+// and out of the journal.
+func setHistorySource(tx *sql.Tx, source string) error {
+	if err := exec(
+		tx,
+		`CREATE TEMP TABLE IF NOT EXISTS "HistorySource" (
+            "ID" integer NOT NULL CHECK ("ID" = 1),
+            "Source" text NOT NULL,
+            PRIMARY KEY ("ID")
+        )`,
+	); err != nil {
+		return err
+	}
+
+	return exec(tx, `INSERT OR REPLACE INTO temp.HistorySource (ID, Source) VALUES (1, ?)`, source)
+}
+
+// addTaskHistory records the current state of the task.
+func addTaskHistory(tx *sql.Tx, id int64, kind string) error {
+	return exec(
+		tx,
+		fmt.Sprintf(
+			`INSERT INTO TaskHistory
+                (TaskID, ChangedAt, Source, Kind, Description, StartedAt, StoppedAt, Deadline, Tags)
+            SELECT "Task"."ID", ?, (SELECT Source FROM temp.HistorySource), ?, "Task"."Description",
+                "Task"."StartedAt", "Task"."StoppedAt", "Task"."Deadline", %s
+            FROM "Task" WHERE "Task"."ID" = ?`,
+			taskTagsSelect(),
+		),
+		util.TimeAsTimestamp(time.Now()),
+		kind,
+		id,
+	)
+}
+
+// addReplayedTasksHistory records the state of the tasks changed by replaying
+// the given journal entry, with the given source.
+func addReplayedTasksHistory(tx *sql.Tx, journalID int64, source string) error {
+	if err := setHistorySource(tx, source); err != nil {
+		return err
+	}
+
+	tasks, err := getReplayedTasks(tx, journalID)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range tasks {
+		switch {
+		case v.exists && v.lastKind.String == historyDelete:
+			err = addTaskHistory(tx, v.id, historyInsert)
+		case v.exists:
+			err = addTaskHistory(tx, v.id, historyUpdate)
+		default:
+			err = exec(
+				tx,
+				`INSERT INTO TaskHistory
+                    (TaskID, ChangedAt, Source, Kind, Description, StartedAt, StoppedAt, Deadline, Tags)
+                SELECT TaskID, ?, ?, ?, Description, StartedAt, StoppedAt, Deadline, Tags
+                FROM TaskHistory WHERE TaskID = ? ORDER BY ID DESC LIMIT 1`,
+				util.TimeAsTimestamp(time.Now()),
+				source,
+				historyDelete,
+				v.id,
+			)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type replayedTask struct {
+	id       int64
+	exists   bool
+	lastKind sql.NullString
+}
+
+// getReplayedTasks returns the tasks changed by the given journal entry,
+// whether they still exist and the kind of their last history entry.
+func getReplayedTasks(tx *sql.Tx, journalID int64) ([]replayedTask, error) {
+	query := `SELECT DISTINCT JournalOp.RowID,
+            EXISTS (SELECT 1 FROM Task WHERE Task.ID = JournalOp.RowID),
+            (
+                SELECT Kind FROM TaskHistory WHERE TaskHistory.TaskID = JournalOp.RowID
+                ORDER BY TaskHistory.ID DESC LIMIT 1
+            )
+        FROM JournalOp WHERE JournalID = ? AND TableName = 'Task'`
+
+	rows, err := tx.Query(query, journalID)
+	if err != nil {
+		return nil, BadQueryError{err, query, []interface{}{journalID}}
+	}
+	defer rows.Close()
+
+	var ret []replayedTask
+	for rows.Next() {
+		var v replayedTask
+		if err := rows.Scan(&v.id, &v.exists, &v.lastKind); err != nil {
+			return nil, BadQueryError{err, query, []interface{}{journalID}}
+		}
+		ret = append(ret, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, BadQueryError{err, query, []interface{}{journalID}}
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,69 @@
+// nolint:thelper
+package tt_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"tt/internal/tt"
+)
+
+func TestTaskHistory(t *testing.T) {
+	app := newTestApp(t)
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.Local)
+
+	app.SetSource(tt.SourceCLI)
+	task, err := app.AddTask("work @acme", day.Add(9*time.Hour), day.Add(10*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app.SetSource(tt.SourceTUI)
+	task.StoppedAt = day.Add(11 * time.Hour)
+	task.Tags = []string{"@bob"}
+	if err := app.UpdateTask(*task); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.DeleteTask(task.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.Redo(); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := app.GetTaskHistory(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		kind, source string
+		stop         time.Duration
+		tag          string
+	}{
+		{"insert", "cli", 10 * time.Hour, "@acme"},
+		{"update", "tui", 11 * time.Hour, "@bob"},
+		{"delete", "tui", 11 * time.Hour, "@bob"},
+		{"insert", "tui undo", 11 * time.Hour, "@bob"},
+		{"delete", "tui redo", 11 * time.Hour, "@bob"},
+	}
+	if len(history) != len(expected) {
+		t.Fatalf("expected %d entries, got %+v", len(expected), history)
+	}
+
+	for i, v := range expected {
+		entry := history[i]
+		if entry.Kind != v.kind || entry.Source != v.source || !entry.Task.StoppedAt.Equal(day.Add(v.stop)) ||
+			len(entry.Task.Tags) != 1 || entry.Task.Tags[0] != v.tag {
+			t.Errorf("entry %d: expected %+v, got %+v", i, v, entry)
+		}
+	}
+
+	if _, err := app.GetTaskHistory(task.ID + 1); !errors.Is(err, tt.ErrInvalidTaskID) {
+		t.Errorf("expected ErrInvalidTaskID, got %v", err)
+	}
+}
@@ -0,0 +1,262 @@
+package tt
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+	"tt/internal/util"
+)
+
+// Holiday is a public holiday, either user-provided or computed from a
+// built-in calendar.
+type Holiday struct {
+	ID   int64 // zero for holidays coming from a built-in calendar
+	Day  time.Time
+	Name string
+}
+
+type holidayCalendar func(year int, loc *time.Location) []Holiday
+
+var holidayCalendars = map[string]holidayCalendar{
+	"fr": frenchHolidays,
+}
+
+// HolidayCalendars returns the names of the built-in holiday calendars.
+func HolidayCalendars() []string {
+	ret := make([]string, 0, len(holidayCalendars))
+	for k := range holidayCalendars {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+
+	return ret
+}
+
+func frenchHolidays(year int, loc *time.Location) []Holiday {
+	var (
+		easter = util.Easter(year, loc)
+		date   = func(month time.Month, day int) time.Time {
+			return time.Date(year, month, day, 0, 0, 0, 0, loc)
+		}
+	)
+
+	return []Holiday{
+		{Day: date(time.January, 1), Name: "New Year's Day"},
+		{Day: easter.AddDate(0, 0, 1), Name: "Easter Monday"},
+		{Day: date(time.May, 1), Name: "Labour Day"},
+		{Day: date(time.May, 8), Name: "Victory in Europe Day"},
+		{Day: easter.AddDate(0, 0, 39), Name: "Ascension Day"},
+		{Day: easter.AddDate(0, 0, 50), Name: "Whit Monday"},
+		{Day: date(time.July, 14), Name: "Bastille Day"},
+		{Day: date(time.August, 15), Name: "Assumption Day"},
+		{Day: date(time.November, 1), Name: "All Saints' Day"},
+		{Day: date(time.November, 11), Name: "Armistice Day"},
+		{Day: date(time.December, 25), Name: "Christmas Day"},
+	}
+}
+
+// holidaySet holds holiday names indexed by day, see dayKey.
+type holidaySet map[string]string
+
+// dayKey indexes data by day.
+func dayKey(day time.Time) string {
+	return day.Format("2006-01-02")
+}
+
+func (set holidaySet) contains(day time.Time) bool {
+	_, ok := set[dayKey(day)]
+	return ok
+}
+
+// getHolidays returns the stored and computed holidays between the two given
+// days, both included, sorted by day.
+func getHolidays(tx *sql.Tx, start, end time.Time) ([]Holiday, error) {
+	start, end = util.GetStartOfDay(start), util.GetStartOfDay(end)
+
+	query := `SELECT ID, Day, Name FROM Holiday WHERE Day >= ? AND Day <= ? ORDER BY Day ASC`
+	rows, err := tx.Query(query, start.Unix(), end.Unix())
+	if err != nil {
+		return nil, BadQueryError{err, query, []interface{}{start, end}}
+	}
+	defer rows.Close()
+
+	var ret []Holiday
+	for rows.Next() {
+		var (
+			v   Holiday
+			day util.TimeAsTimestamp
+		)
+
+		if err := rows.Scan(&v.ID, &day, &v.Name); err != nil {
+			return nil, BadQueryError{err, query, nil}
+		}
+
+		v.Day = day.Time()
+		ret = append(ret, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, BadQueryError{err, query, nil}
+	}
+
+	name, err := getConfig(tx, configHolidayCalendar)
+	if err != nil {
+		return nil, err
+	}
+
+	if calendar, ok := holidayCalendars[name]; ok {
+		stored := make(map[string]struct{}, len(ret))
+		for _, v := range ret {
+			stored[dayKey(v.Day)] = struct{}{}
+		}
+
+		for year := start.Year(); year <= end.Year(); year++ {
+			for _, v := range calendar(year, start.Location()) {
+				if _, ok := stored[dayKey(v.Day)]; ok || v.Day.Before(start) || v.Day.After(end) {
+					continue
+				}
+
+				ret = append(ret, v)
+			}
+		}
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].Day.Before(ret[j].Day)
+	})
+
+	return ret, nil
+}
+
+func getHolidaySet(tx *sql.Tx, start, end time.Time) (holidaySet, error) {
+	holidays, err := getHolidays(tx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(holidaySet, len(holidays))
+	for _, v := range holidays {
+		ret[dayKey(v.Day)] = v.Name
+	}
+
+	return ret, nil
+}
+
+// GetHolidays returns the holidays of the given year.
+func (tt *TT) GetHolidays(year int) ([]Holiday, error) {
+	var (
+		ret   []Holiday
+		start = time.Date(year, time.January, 1, 0, 0, 0, 0, time.Now().Location())
+		end   = start.AddDate(1, 0, -1)
+	)
+
+	if err := tt.readTransaction(func(tx *sql.Tx) (err error) {
+		ret, err = getHolidays(tx, start, end)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// AddHoliday adds a user-provided holiday, replacing the existing one on the
+// same day if any.
+func (tt *TT) AddHoliday(day time.Time, name string) (Holiday, error) {
+	ret := Holiday{Day: util.GetStartOfDay(day), Name: name}
+	if name == "" {
+		return Holiday{}, InvalidInputError("a holiday needs a name")
+	}
+
+	err := tt.transaction(func(tx *sql.Tx) (err error) {
+		ret.ID, err = execWithLastID(
+			tx,
+			`INSERT OR REPLACE INTO Holiday (Day, Name) VALUES (?, ?)`,
+			ret.Day.Unix(),
+			ret.Name,
+		)
+		return err
+	})
+
+	return ret, err
+}
+
+// DeleteHoliday removes a user-provided holiday.
+func (tt *TT) DeleteHoliday(id int64) error {
+	return tt.transaction(func(tx *sql.Tx) error {
+		n, err := execWithRowsAffected(tx, `DELETE FROM Holiday WHERE ID = ?`, id)
+		if err != nil {
+			return err
+		}
+
+		if n == 0 {
+			return ErrInvalidHolidayID
+		}
+
+		return nil
+	})
+}
+
+// ImportHolidays adds every day of every event of an iCalendar file as a
+// holiday and returns the number of days added, days that already are
+// holidays are skipped.
+func (tt *TT) ImportHolidays(r io.Reader) (int, error) {
+	events, err := util.ParseICS(r, time.Now().Location())
+	if err != nil {
+		return 0, InvalidInputError(fmt.Sprintf("unable to parse calendar: %s", err))
+	}
+
+	var count int
+	err = tt.transaction(func(tx *sql.Tx) error {
+		for _, event := range events {
+			day := util.GetStartOfDay(event.Start)
+			end := event.End
+			if end.IsZero() || !end.After(day) {
+				end = day.AddDate(0, 0, 1)
+			}
+
+			for ; day.Before(end); day = day.AddDate(0, 0, 1) {
+				n, err := execWithRowsAffected(
+					tx,
+					`INSERT OR IGNORE INTO Holiday (Day, Name) VALUES (?, ?)`,
+					day.Unix(),
+					event.Summary,
+				)
+				if err != nil {
+					return err
+				}
+				count += int(n)
+			}
+		}
+
+		return nil
+	})
+
+	return count, err
+}
+
+// HolidayCalendar returns the name of the built-in calendar in use, if any.
+func (tt *TT) HolidayCalendar() (string, error) {
+	var ret string
+
+	err := tt.readTransaction(func(tx *sql.Tx) (err error) {
+		ret, err = getConfig(tx, configHolidayCalendar)
+		return err
+	})
+
+	return ret, err
+}
+
+// SetHolidayCalendar selects the built-in calendar to use on top of the
+// user-provided holidays, an empty name disables built-in calendars.
+func (tt *TT) SetHolidayCalendar(name string) error {
+	if _, ok := holidayCalendars[name]; !ok && name != "" {
+		return InvalidInputError(fmt.Sprintf("unknown holiday calendar: %s", name))
+	}
+
+	return tt.transaction(func(tx *sql.Tx) error {
+		return setConfig(tx, configHolidayCalendar, name)
+	})
+}
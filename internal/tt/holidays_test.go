@@ -0,0 +1,66 @@
+// nolint:thelper
+package tt_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"tt/internal/tt"
+)
+
+func TestHolidays(t *testing.T) {
+	app := newTestApp(t)
+
+	if err := app.SetHolidayCalendar("nowhere"); err == nil {
+		t.Error("expected an error for an unknown calendar")
+	}
+	if err := app.SetHolidayCalendar("fr"); err != nil {
+		t.Fatal(err)
+	}
+
+	added, err := app.AddHoliday(time.Date(2024, 6, 3, 12, 0, 0, 0, time.Local), "Company day")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calendar := "BEGIN:VEVENT\nDTSTART;VALUE=DATE:20240722\nDTEND;VALUE=DATE:20240724\nSUMMARY:Closed\nEND:VEVENT\n"
+	count, err := app.ImportHolidays(strings.NewReader(calendar))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 imported days, got %d", count)
+	}
+
+	// The days already are holidays on a re-import.
+	if count, err = app.ImportHolidays(strings.NewReader(calendar)); err != nil || count != 0 {
+		t.Errorf("expected no day imported again, got %d (%v)", count, err)
+	}
+
+	holidays, err := app.GetHolidays(2024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	days := make(map[string]tt.Holiday, len(holidays))
+	for _, v := range holidays {
+		days[v.Day.Format("2006-01-02")] = v
+	}
+
+	for _, v := range []string{"2024-04-01", "2024-05-09", "2024-05-20", "2024-06-03", "2024-07-22", "2024-07-23"} {
+		if _, ok := days[v]; !ok {
+			t.Errorf("expected %s to be a holiday", v)
+		}
+	}
+	if len(holidays) != 11+3 {
+		t.Errorf("expected 14 holidays, got %d", len(holidays))
+	}
+
+	if err := app.DeleteHoliday(added.ID); err != nil {
+		t.Error(err)
+	}
+	if err := app.DeleteHoliday(added.ID); !errors.Is(err, tt.ErrInvalidHolidayID) {
+		t.Errorf("expected ErrInvalidHolidayID, got %v", err)
+	}
+}
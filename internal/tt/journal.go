@@ -0,0 +1,374 @@
+package tt
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"tt/internal/util"
+)
+
+// Older journal entries are forgotten.
+const journalMaxEntries = 100
+
+// Columns used to describe a changed row in the journal, by order of
+// preference.
+var journalLabelColumns = []string{"Description", "Name", "Key", "Rule"}
+
+// JournalEntry is a transaction that changed the database, it can be undone
+// and redone.
+type JournalEntry struct {
+	ID        int64
+	CreatedAt time.Time
+	Undone    bool
+	Changes   []JournalChange
+}
+
+// JournalChange is a row that was inserted, updated or deleted.
+type JournalChange struct {
+	Kind  string // insert, update or delete
+	Table string
+	RowID int64
+	Label string // description of the row, if any
+}
+
+// Undo reverts the last change to the database that was not undone yet.
+func (tt *TT) Undo() (*JournalEntry, error) {
+	return tt.replayJournal(true)
+}
+
+// Redo applies again the first undone change.
+func (tt *TT) Redo() (*JournalEntry, error) {
+	return tt.replayJournal(false)
+}
+
+func (tt *TT) replayJournal(undo bool) (*JournalEntry, error) {
+	var entry *JournalEntry
+
+	err := tt.transaction(func(tx *sql.Tx) error {
+		query := `SELECT ID FROM Journal WHERE Undone = 0 ORDER BY ID DESC LIMIT 1`
+		order := `DESC`
+		if !undo {
+			query = `SELECT ID FROM Journal WHERE Undone = 1 ORDER BY ID ASC LIMIT 1`
+			order = `ASC`
+		}
+
+		var id int64
+		if err := tx.QueryRow(query).Scan(&id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				if undo {
+					return ErrNothingToUndo
+				}
+				return ErrNothingToRedo
+			}
+			return BadQueryError{err, query, nil}
+		}
+
+		column, source := `UndoSQL`, tt.source+" undo"
+		if !undo {
+			column, source = `RedoSQL`, tt.source+" redo"
+		}
+
+		statements, err := queryStrings(tx, fmt.Sprintf(
+			`SELECT %s FROM JournalOp WHERE JournalID = ? ORDER BY ID %s`, column, order,
+		), id)
+		if err != nil {
+			return err
+		}
+
+		if err := execAll(tx, statements); err != nil {
+			return err
+		}
+
+		// The replay itself must not be recorded.
+		if err := exec(tx, `DELETE FROM JournalOp WHERE JournalID IS NULL`); err != nil {
+			return err
+		}
+
+		if err := addReplayedTasksHistory(tx, id, source); err != nil {
+			return err
+		}
+
+		if err := exec(tx, `UPDATE Journal SET Undone = ? WHERE ID = ?`, undo, id); err != nil {
+			return err
+		}
+
+		entries, err := getJournal(tx, `WHERE ID = ?`, id)
+		if err != nil {
+			return err
+		}
+		entry = &entries[0]
+
+		return nil
+	})
+
+	return entry, err
+}
+
+// GetJournal returns the recorded changes, the most recent first.
+func (tt *TT) GetJournal() ([]JournalEntry, error) {
+	var ret []JournalEntry
+
+	err := tt.readTransaction(func(tx *sql.Tx) (err error) {
+		ret, err = getJournal(tx, ``)
+		return err
+	})
+
+	return ret, err
+}
+
+func getJournal(tx *sql.Tx, where string, params ...interface{}) ([]JournalEntry, error) {
+	query := fmt.Sprintf(
+		`SELECT Journal.ID, Journal.CreatedAt, Journal.Undone,
+            JournalOp.Kind, JournalOp.TableName, JournalOp.RowID, IFNULL(JournalOp.Label, '')
+        FROM (SELECT * FROM Journal %s) AS Journal
+        JOIN JournalOp ON JournalOp.JournalID = Journal.ID
+        ORDER BY Journal.ID DESC, JournalOp.ID ASC`,
+		where,
+	)
+
+	rows, err := tx.Query(query, params...)
+	if err != nil {
+		return nil, BadQueryError{err, query, params}
+	}
+	defer rows.Close()
+
+	var ret []JournalEntry
+	for rows.Next() {
+		var (
+			entry     JournalEntry
+			change    JournalChange
+			createdAt util.TimeAsTimestamp
+		)
+
+		if err := rows.Scan(
+			&entry.ID, &createdAt, &entry.Undone,
+			&change.Kind, &change.Table, &change.RowID, &change.Label,
+		); err != nil {
+			return nil, BadQueryError{err, query, params}
+		}
+
+		if len(ret) == 0 || ret[len(ret)-1].ID != entry.ID {
+			entry.CreatedAt = createdAt.Time()
+			ret = append(ret, entry)
+		}
+
+		last := &ret[len(ret)-1]
+		last.Changes = append(last.Changes, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, BadQueryError{err, query, params}
+	}
+
+	return ret, nil
+}
+
+// recordJournal groups the changes made by the triggers during the current
+// transaction in a new journal entry. Undone entries can't be redone anymore.
+func recordJournal(tx *sql.Tx) error {
+	var count int
+	query := `SELECT COUNT(*) FROM JournalOp WHERE JournalID IS NULL`
+	if err := tx.QueryRow(query).Scan(&count); err != nil {
+		return BadQueryError{err, query, nil}
+	}
+
+	if count == 0 {
+		return nil
+	}
+
+	id, err := execWithLastID(
+		tx,
+		`INSERT INTO Journal (CreatedAt) VALUES (?)`,
+		util.TimeAsTimestamp(time.Now()),
+	)
+	if err != nil {
+		return err
+	}
+
+	return execAll(tx, []string{
+		fmt.Sprintf(`UPDATE JournalOp SET JournalID = %d WHERE JournalID IS NULL`, id),
+		`DELETE FROM Journal WHERE Undone = 1`,
+		fmt.Sprintf(`DELETE FROM Journal WHERE ID <= %d`, id-journalMaxEntries),
+		`DELETE FROM JournalOp WHERE JournalID NOT IN (SELECT ID FROM Journal)`,
+	})
+}
+
+// unjournaledTransaction is transaction, but its changes are not recorded in
+// the journal. It is meant for the changes tt makes on its own, like stopping a
+// task at its deadline: undoing them would only revert them until the next
+// invocation makes them again, instead of the user's last change.
+func (tt *TT) unjournaledTransaction(cb transactionCallback) error {
+	return tt.transaction(func(tx *sql.Tx) error {
+		if err := cb(tx); err != nil {
+			return err
+		}
+
+		return exec(tx, `DELETE FROM JournalOp WHERE JournalID IS NULL`)
+	})
+}
+
+// journaledTables returns the tables whose changes are recorded.
+func journaledTables(tx *sql.Tx) ([]string, error) {
+	return queryStrings(
+		tx,
+		`SELECT name FROM sqlite_master
+        WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name NOT IN ('Journal', 'JournalOp', 'TaskHistory')
+        ORDER BY name`,
+	)
+}
+
+func dropJournalTriggers(tx *sql.Tx) error {
+	triggers, err := queryStrings(
+		tx,
+		`SELECT name FROM sqlite_master WHERE type = 'trigger' AND name LIKE 'Journal\_%' ESCAPE '\'`,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range triggers {
+		if err := exec(tx, fmt.Sprintf(`DROP TRIGGER "%s"`, v)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// updateJournalTriggers (re)creates the triggers recording the inverse of
+// every change, from the current schema. The journal is emptied since it
+// might not apply to the current schema.
+func updateJournalTriggers(tx *sql.Tx) error {
+	if err := dropJournalTriggers(tx); err != nil {
+		return err
+	}
+
+	tables, err := journaledTables(tx)
+	if err != nil {
+		return err
+	}
+
+	for _, table := range tables {
+		columns, err := queryStrings(tx, `SELECT name FROM pragma_table_info(?) ORDER BY cid`, table)
+		if err != nil {
+			return err
+		}
+
+		for _, v := range journalTriggers(table, columns) {
+			if err := exec(tx, v); err != nil {
+				return err
+			}
+		}
+	}
+
+	return execAll(tx, []string{
+		`DELETE FROM JournalOp`,
+		`DELETE FROM Journal`,
+	})
+}
+
+// journalTriggers returns the statements creating the triggers of a table,
+// they write the SQL that undoes and redoes each change.
+func journalTriggers(table string, columns []string) []string {
+	var (
+		label   = `NULL`
+		insert  = func(row string) string { return sqlInsertRow(table, columns, row) }
+		update  = func(row string) string { return sqlUpdateRow(table, columns, row) }
+		deleted = func(row string) string {
+			return fmt.Sprintf(`'DELETE FROM "%s" WHERE rowid = ' || %s.rowid`, table, row)
+		}
+	)
+
+	for _, v := range journalLabelColumns {
+		if contains(columns, v) {
+			label = fmt.Sprintf(`%%s."%s"`, v)
+			break
+		}
+	}
+
+	trigger := func(kind, row, undo, redo string) string {
+		return fmt.Sprintf(
+			`CREATE TRIGGER "Journal_%[1]s_%[2]s" AFTER %[3]s ON "%[1]s"
+            BEGIN
+                INSERT INTO JournalOp (Kind, TableName, RowID, Label, UndoSQL, RedoSQL)
+                VALUES ('%[2]s', '%[1]s', %[4]s.rowid, %[5]s, %[6]s, %[7]s);
+            END`,
+			table, kind, strings.ToUpper(kind), row, strings.ReplaceAll(label, "%s", row), undo, redo,
+		)
+	}
+
+	return []string{
+		trigger("insert", "NEW", deleted("NEW"), insert("NEW")),
+		trigger("update", "NEW", update("OLD"), update("NEW")),
+		trigger("delete", "OLD", insert("OLD"), deleted("OLD")),
+	}
+}
+
+// sqlInsertRow returns a SQL expression building an INSERT of the given row.
+func sqlInsertRow(table string, columns []string, row string) string {
+	names := make([]string, 0, len(columns))
+	values := make([]string, 0, len(columns))
+	for _, v := range columns {
+		names = append(names, fmt.Sprintf(`"%s"`, v))
+		values = append(values, fmt.Sprintf(`quote(%s."%s")`, row, v))
+	}
+
+	return fmt.Sprintf(
+		`'INSERT INTO "%s" (rowid, %s) VALUES (' || %s.rowid || ', ' || %s || ')'`,
+		table,
+		strings.Join(names, ", "),
+		row,
+		strings.Join(values, ` || ', ' || `),
+	)
+}
+
+// sqlUpdateRow returns a SQL expression building an UPDATE setting the values
+// of the given row.
+func sqlUpdateRow(table string, columns []string, row string) string {
+	sets := make([]string, 0, len(columns))
+	for _, v := range columns {
+		sets = append(sets, fmt.Sprintf(`'"%s" = ' || quote(%s."%s")`, v, row, v))
+	}
+
+	return fmt.Sprintf(
+		`'UPDATE "%s" SET ' || %s || ' WHERE rowid = ' || %s.rowid`,
+		table,
+		strings.Join(sets, ` || ', ' || `),
+		row,
+	)
+}
+
+func queryStrings(tx *sql.Tx, query string, params ...interface{}) ([]string, error) {
+	rows, err := tx.Query(query, params...)
+	if err != nil {
+		return nil, BadQueryError{err, query, params}
+	}
+	defer rows.Close()
+
+	var ret []string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, BadQueryError{err, query, params}
+		}
+		ret = append(ret, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, BadQueryError{err, query, params}
+	}
+
+	return ret, nil
+}
+
+func contains(list []string, v string) bool {
+	for _, w := range list {
+		if w == v {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,115 @@
+// nolint:thelper
+package tt_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+	"tt/internal/tt"
+)
+
+func TestUndoRedo(t *testing.T) {
+	app := newTestApp(t)
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.Local)
+
+	if _, err := app.Undo(); !errors.Is(err, tt.ErrNothingToUndo) {
+		t.Fatalf("expected ErrNothingToUndo, got %v", err)
+	}
+
+	task, err := app.AddTask("work @acme @bob", day.Add(9*time.Hour), day.Add(10*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.DeleteTask(task.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := app.Undo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entry.Changes) == 0 || entry.Changes[0].Kind != "delete" || !entry.Undone {
+		t.Errorf("unexpected undone entry: %+v", entry)
+	}
+
+	restored, err := app.GetTask(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*restored, *task) {
+		t.Errorf("expected %+v, got %+v", task, restored)
+	}
+
+	// Undoing the insert as well leaves nothing.
+	if _, err := app.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.GetTasks(); !errors.Is(err, tt.ErrNoTasks) {
+		t.Errorf("expected no tasks, got %v", err)
+	}
+
+	if _, err := app.Redo(); err != nil {
+		t.Fatal(err)
+	}
+	if restored, err = app.GetTask(task.ID); err != nil || !reflect.DeepEqual(*restored, *task) {
+		t.Errorf("expected %+v, got %+v (%v)", task, restored, err)
+	}
+
+	// A new change drops the undone entries.
+	if _, err := app.AddTask("meeting", day.Add(11*time.Hour), day.Add(12*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.Redo(); !errors.Is(err, tt.ErrNothingToRedo) {
+		t.Errorf("expected ErrNothingToRedo, got %v", err)
+	}
+
+	journal, err := app.GetJournal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(journal) != 2 || journal[0].Changes[0].Label != "meeting" || journal[1].Changes[0].Label != "work" {
+		t.Errorf("unexpected journal: %+v", journal)
+	}
+}
+
+func TestUndoSkipsExpiredTask(t *testing.T) {
+	app := newTestApp(t)
+	now := time.Now()
+
+	_, timeboxed, err := app.StartFor("timeboxed", now.Add(-2*time.Hour), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	added, err := app.AddTask("added", now.Add(-4*time.Hour), now.Add(-3*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expired, err := app.ExpireTask()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expired == nil || expired.ID != timeboxed.ID {
+		t.Fatalf("expected the timeboxed task to expire, got %+v", expired)
+	}
+
+	// The automatic stop is kept, the user's last change is undone.
+	entry, err := app.Undo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entry.Changes) == 0 || entry.Changes[0].RowID != added.ID {
+		t.Errorf("expected the added task to be undone, got %+v", entry)
+	}
+
+	task, err := app.GetTask(timeboxed.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !task.StoppedAt.Equal(expired.StoppedAt) {
+		t.Errorf("expected the task to stay stopped at %s, got %+v", expired.StoppedAt, task)
+	}
+}
@@ -0,0 +1,356 @@
+package tt
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"tt/internal/util"
+)
+
+type LeaveType string
+
+const (
+	LeavePaid   LeaveType = "paid"
+	LeaveSick   LeaveType = "sick"
+	LeaveUnpaid LeaveType = "unpaid"
+	LeaveRTT    LeaveType = "rtt" // French "réduction du temps de travail" days
+)
+
+// LeaveTypes lists all known leave types.
+var LeaveTypes = []LeaveType{LeavePaid, LeaveSick, LeaveUnpaid, LeaveRTT}
+
+// ParseLeaveType returns the LeaveType matching the given name,
+// case-insensitive.
+func ParseLeaveType(name string) (LeaveType, error) {
+	for _, v := range LeaveTypes {
+		if strings.EqualFold(string(v), name) {
+			return v, nil
+		}
+	}
+
+	return "", InvalidInputError(fmt.Sprintf("unknown leave type: %s", name))
+}
+
+// Leave is a day or half-day off work, it credits the day target in reports.
+type Leave struct {
+	ID      int64
+	Day     time.Time
+	Type    LeaveType
+	Portion float64 // 1 for a full day, 0.5 for a half-day
+}
+
+// LeaveBalance holds the leave days of a type for a year, in days.
+type LeaveBalance struct {
+	Type      LeaveType
+	Year      int
+	Allowance float64
+	CarryOver float64 // remaining days carried over from the previous year
+	Taken     float64
+	Remaining float64
+}
+
+// leaveSet holds the portion of the day taken off, indexed by day, see dayKey.
+type leaveSet map[string]float64
+
+// credit returns the part of the target covered by leave.
+func (set leaveSet) credit(day time.Time, target time.Duration) time.Duration {
+	portion := set[dayKey(day)]
+	if portion > 1 {
+		portion = 1
+	}
+
+	return time.Duration(float64(target) * portion)
+}
+
+func getLeaves(tx *sql.Tx, start, end time.Time) ([]Leave, error) {
+	start, end = util.GetStartOfDay(start), util.GetStartOfDay(end)
+
+	query := `SELECT ID, Day, Type, Portion FROM Leave WHERE Day >= ? AND Day <= ? ORDER BY Day ASC, ID ASC`
+	rows, err := tx.Query(query, start.Unix(), end.Unix())
+	if err != nil {
+		return nil, BadQueryError{err, query, []interface{}{start, end}}
+	}
+	defer rows.Close()
+
+	var ret []Leave
+	for rows.Next() {
+		var (
+			v   Leave
+			day util.TimeAsTimestamp
+		)
+
+		if err := rows.Scan(&v.ID, &day, &v.Type, &v.Portion); err != nil {
+			return nil, BadQueryError{err, query, nil}
+		}
+
+		v.Day = day.Time()
+		ret = append(ret, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, BadQueryError{err, query, nil}
+	}
+
+	return ret, nil
+}
+
+func getLeaveSet(tx *sql.Tx, start, end time.Time) (leaveSet, error) {
+	leaves, err := getLeaves(tx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(leaveSet, len(leaves))
+	for _, v := range leaves {
+		ret[dayKey(v.Day)] += v.Portion
+	}
+
+	return ret, nil
+}
+
+// GetLeaves returns the leave days between the two given days, both
+// included.
+func (tt *TT) GetLeaves(start, end time.Time) ([]Leave, error) {
+	var ret []Leave
+
+	if err := tt.readTransaction(func(tx *sql.Tx) (err error) {
+		ret, err = getLeaves(tx, start, end)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// AddLeave records leave on every workday between the two given days, both
+// included, and returns the created entries. Days off and holidays are
+// skipped.
+func (tt *TT) AddLeave(start, end time.Time, leaveType LeaveType, portion float64) ([]Leave, error) {
+	if _, err := ParseLeaveType(string(leaveType)); err != nil {
+		return nil, err
+	}
+
+	if portion != 1 && portion != 0.5 {
+		return nil, InvalidInputError(fmt.Sprintf("invalid leave portion, expected a full or half day: %v", portion))
+	}
+
+	start, end = util.GetStartOfDay(start), util.GetStartOfDay(end)
+	if end.Before(start) {
+		return nil, InvalidInputError("leave cannot end before it starts")
+	}
+
+	var ret []Leave
+	err := tt.transaction(func(tx *sql.Tx) error {
+		timeline, err := getRulesTimeline(tx)
+		if err != nil {
+			return err
+		}
+
+		holidays, err := getHolidaySet(tx, start, end)
+		if err != nil {
+			return err
+		}
+
+		existing, err := getLeaveSet(tx, start, end)
+		if err != nil {
+			return err
+		}
+
+		for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+			if holidays.contains(day) || timeline.forDay(day).isOffDay(day.Weekday()) {
+				continue
+			}
+
+			if existing[dayKey(day)]+portion > 1 {
+				return InvalidInputError(fmt.Sprintf("more than a day of leave on %s", day.Format("2006-01-02")))
+			}
+
+			leave := Leave{Day: day, Type: leaveType, Portion: portion}
+			if leave.ID, err = execWithLastID(
+				tx,
+				`INSERT INTO Leave (Day, Type, Portion) VALUES (?, ?, ?)`,
+				leave.Day.Unix(), leave.Type, leave.Portion,
+			); err != nil {
+				return err
+			}
+
+			ret = append(ret, leave)
+		}
+
+		if len(ret) == 0 {
+			return InvalidInputError("no workday in the given range")
+		}
+
+		return nil
+	})
+
+	return ret, err
+}
+
+// DeleteLeave removes a leave entry.
+func (tt *TT) DeleteLeave(id int64) error {
+	return tt.transaction(func(tx *sql.Tx) error {
+		n, err := execWithRowsAffected(tx, `DELETE FROM Leave WHERE ID = ?`, id)
+		if err != nil {
+			return err
+		}
+
+		if n == 0 {
+			return ErrInvalidLeaveID
+		}
+
+		return nil
+	})
+}
+
+// SetLeaveAllowance sets the number of days given every year for a leave
+// type and the maximum number of remaining days that can be carried over to
+// the next year.
+func (tt *TT) SetLeaveAllowance(leaveType LeaveType, daysPerYear, maxCarryOver float64) error {
+	if _, err := ParseLeaveType(string(leaveType)); err != nil {
+		return err
+	}
+
+	if daysPerYear < 0 || maxCarryOver < 0 {
+		return InvalidInputError("leave allowance cannot be negative")
+	}
+
+	return tt.transaction(func(tx *sql.Tx) error {
+		return exec(
+			tx,
+			`INSERT OR REPLACE INTO LeaveAllowance (Type, DaysPerYear, MaxCarryOver) VALUES (?, ?, ?)`,
+			leaveType, daysPerYear, maxCarryOver,
+		)
+	})
+}
+
+// GetLeaveBalance returns the balance of every leave type for the given year.
+// Balances are computed from the year of the first leave entry, carrying the
+// remaining days over from one year to the next.
+func (tt *TT) GetLeaveBalance(year int) ([]LeaveBalance, error) {
+	var ret []LeaveBalance
+
+	err := tt.readTransaction(func(tx *sql.Tx) error {
+		allowances, err := getLeaveAllowances(tx)
+		if err != nil {
+			return err
+		}
+
+		taken, firstYear, err := getTakenLeaveByYear(tx)
+		if err != nil {
+			return err
+		}
+
+		if firstYear == 0 || firstYear > year {
+			firstYear = year
+		}
+
+		for _, leaveType := range LeaveTypes {
+			var (
+				allowance = allowances[leaveType]
+				balance   LeaveBalance
+			)
+
+			for y := firstYear; y <= year; y++ {
+				carry := balance.Remaining
+				if carry > allowance.maxCarryOver {
+					carry = allowance.maxCarryOver
+				}
+
+				balance = LeaveBalance{
+					Type:      leaveType,
+					Year:      y,
+					Allowance: allowance.daysPerYear,
+					CarryOver: carry,
+					Taken:     taken[leaveType][y],
+				}
+				balance.Remaining = balance.Allowance + balance.CarryOver - balance.Taken
+			}
+
+			ret = append(ret, balance)
+		}
+
+		return nil
+	})
+
+	return ret, err
+}
+
+type leaveAllowance struct {
+	daysPerYear, maxCarryOver float64
+}
+
+func getLeaveAllowances(tx *sql.Tx) (map[LeaveType]leaveAllowance, error) {
+	query := `SELECT Type, DaysPerYear, MaxCarryOver FROM LeaveAllowance`
+	rows, err := tx.Query(query)
+	if err != nil {
+		return nil, BadQueryError{err, query, nil}
+	}
+	defer rows.Close()
+
+	ret := make(map[LeaveType]leaveAllowance, len(LeaveTypes))
+	for rows.Next() {
+		var (
+			leaveType LeaveType
+			v         leaveAllowance
+		)
+
+		if err := rows.Scan(&leaveType, &v.daysPerYear, &v.maxCarryOver); err != nil {
+			return nil, BadQueryError{err, query, nil}
+		}
+
+		ret[leaveType] = v
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, BadQueryError{err, query, nil}
+	}
+
+	return ret, nil
+}
+
+// getTakenLeaveByYear returns the number of leave days taken per type and
+// year, and the first year with leave.
+func getTakenLeaveByYear(tx *sql.Tx) (map[LeaveType]map[int]float64, int, error) {
+	query := `SELECT Day, Type, Portion FROM Leave ORDER BY Day ASC`
+	rows, err := tx.Query(query)
+	if err != nil {
+		return nil, 0, BadQueryError{err, query, nil}
+	}
+	defer rows.Close()
+
+	var (
+		ret       = make(map[LeaveType]map[int]float64, len(LeaveTypes))
+		firstYear int
+	)
+
+	for rows.Next() {
+		var (
+			day       util.TimeAsTimestamp
+			leaveType LeaveType
+			portion   float64
+		)
+
+		if err := rows.Scan(&day, &leaveType, &portion); err != nil {
+			return nil, 0, BadQueryError{err, query, nil}
+		}
+
+		year := day.Time().Year()
+		if firstYear == 0 {
+			firstYear = year
+		}
+
+		if ret[leaveType] == nil {
+			ret[leaveType] = map[int]float64{}
+		}
+		ret[leaveType][year] += portion
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, BadQueryError{err, query, nil}
+	}
+
+	return ret, firstYear, nil
+}
@@ -0,0 +1,63 @@
+// nolint:thelper
+package tt_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"tt/internal/tt"
+)
+
+func TestLeave(t *testing.T) {
+	app := newTestApp(t)
+
+	if err := app.SetLeaveAllowance(tt.LeavePaid, 25, 5); err != nil {
+		t.Fatal(err)
+	}
+
+	// Friday to the next Friday, 6 workdays.
+	start := time.Date(2023, 3, 3, 0, 0, 0, 0, time.Local)
+	leaves, err := app.AddLeave(start, start.AddDate(0, 0, 7), tt.LeavePaid, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leaves) != 6 {
+		t.Errorf("expected 6 days of leave, got %d", len(leaves))
+	}
+
+	if _, err := app.AddLeave(start, start, tt.LeaveSick, 0.5); err == nil {
+		t.Error("expected an error when taking more than a day of leave")
+	}
+	if _, err := app.AddLeave(start.AddDate(0, 0, 1), start.AddDate(0, 0, 2), tt.LeavePaid, 1); err == nil {
+		t.Error("expected an error when taking leave on a weekend")
+	}
+
+	next := time.Date(2024, 3, 4, 0, 0, 0, 0, time.Local)
+	if _, err := app.AddLeave(next, next, tt.LeavePaid, 0.5); err != nil {
+		t.Fatal(err)
+	}
+
+	balances, err := app.GetLeaveBalance(2024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := tt.LeaveBalance{
+		Type:      tt.LeavePaid,
+		Year:      2024,
+		Allowance: 25,
+		CarryOver: 5,
+		Taken:     0.5,
+		Remaining: 29.5,
+	}
+	if balances[0] != expected {
+		t.Errorf("expected %+v, got %+v", expected, balances[0])
+	}
+
+	if err := app.DeleteLeave(leaves[0].ID); err != nil {
+		t.Error(err)
+	}
+	if err := app.DeleteLeave(leaves[0].ID); !errors.Is(err, tt.ErrInvalidLeaveID) {
+		t.Errorf("expected ErrInvalidLeaveID, got %v", err)
+	}
+}
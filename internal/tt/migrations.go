@@ -3,28 +3,167 @@ package tt
 import (
 	"database/sql"
 	"fmt"
+	"os"
+	"time"
 )
 
-func migrate(db *sql.DB) (err error) {
-	cur := getCurrentMigrationVersion(db)
+// migration is a single schema change, migrations are applied in order and
+// the number of applied migrations is stored in Config.MigrationVersion.
+// Never remove or reorder migrations, only append new ones.
+type migration struct {
+	name string
+	up   func(tx *sql.Tx) error
+}
+
+// nolint:gochecknoglobals
+var migrations = []migration{
+	{"initial schema", doInitialMigration},
+	{"overtime rules", doRulesMigration},
+	{"public holidays", doHolidaysMigration},
+	{"leave", doLeaveMigration},
+	{"normalized tags", doTagsMigration},
+	{"task stack", doTaskStackMigration},
+	{"task deadline", doTaskDeadlineMigration},
+	{"forgotten tasks detection", doForgottenTasksMigration},
+	{"undo journal", doJournalMigration},
+	{"task history", doTaskHistoryMigration},
+	{"single running task", doSingleRunningTaskMigration},
+	{"task time indexes", doTaskTimeIndexesMigration},
+}
+
+// MigrationStep describes a migration and whether it was applied to the DB.
+type MigrationStep struct {
+	Version int
+	Name    string
+	Applied bool
+}
+
+// MigrationStatus returns the current database version and the list of all
+// known migrations.
+func (tt *TT) MigrationStatus() (int, []MigrationStep, error) {
+	cur := getCurrentMigrationVersion(tt.db)
+	if err := checkMigrationVersion(cur); err != nil {
+		return cur, nil, err
+	}
+
+	steps := make([]MigrationStep, 0, len(migrations))
+	for i, m := range migrations {
+		steps = append(steps, MigrationStep{
+			Version: i + 1,
+			Name:    m.name,
+			Applied: i < cur,
+		})
+	}
+
+	return cur, steps, nil
+}
+
+// Migrate applies all pending migrations, each in its own transaction.
+// Existing databases are backed up next to the DB file before being changed.
+func (tt *TT) Migrate() error {
+	cur := getCurrentMigrationVersion(tt.db)
+	if err := checkMigrationVersion(cur); err != nil {
+		return err
+	}
+
+	if cur == len(migrations) {
+		tt.migrated = true
+		return nil
+	}
+
+	if cur > 0 {
+		if _, err := tt.backup(cur); err != nil {
+			return err
+		}
+	}
+
+	// The journal triggers would prevent altering the tables.
+	if err := tt.transaction(dropJournalTriggers); err != nil {
+		return err
+	}
+
+	for version := cur + 1; version <= len(migrations); version++ {
+		if err := tt.transaction(func(tx *sql.Tx) error {
+			// Another process may have applied it since cur was read.
+			if getCurrentMigrationVersion(tx) >= version {
+				return nil
+			}
+
+			return applyMigration(tx, version)
+		}); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", version, migrations[version-1].name, err)
+		}
+	}
+
+	if err := tt.transaction(updateJournalTriggers); err != nil {
+		return err
+	}
+	tt.migrated = true
+
+	return nil
+}
+
+func checkMigrationVersion(cur int) error {
 	if cur < 0 {
 		return DatabaseError(fmt.Sprintf("invalid database version: %d", cur))
 	}
 
-	switch cur {
-	case 0:
-		err = doInitialMigration(db)
-		fallthrough
-	case 1:
-		break // current version
-	default:
+	if cur > len(migrations) {
 		return DatabaseError(fmt.Sprintf("database is at version %d which is not compatible with your local tt version", cur))
 	}
 
-	return err
+	return nil
+}
+
+func applyMigration(tx *sql.Tx, version int) error {
+	if err := migrations[version-1].up(tx); err != nil {
+		return err
+	}
+
+	return exec(
+		tx,
+		`INSERT OR REPLACE INTO Config (Key, Value) VALUES ('MigrationVersion', ?)`,
+		version,
+	)
 }
 
-func getCurrentMigrationVersion(db *sql.DB) int {
+// backup copies the database next to its file and returns the backup path,
+// or an empty string if the database has no file (eg. in-memory databases).
+func (tt *TT) backup(version int) (string, error) {
+	path, err := tt.dbPath()
+	if err != nil || path == "" {
+		return "", err
+	}
+
+	dst := fmt.Sprintf("%s.%s.v%d.bak", path, time.Now().Format("20060102150405"), version)
+	if _, err := os.Stat(dst); err == nil {
+		return "", IOError{"backup file already exists", dst, nil}
+	}
+
+	if _, err := tt.db.Exec(`VACUUM INTO ?`, dst); err != nil {
+		return "", IOError{"unable to back up the database", dst, err}
+	}
+
+	return dst, nil
+}
+
+func (tt *TT) dbPath() (string, error) {
+	query := `SELECT file FROM pragma_database_list WHERE name = 'main'`
+
+	var path string
+	if err := tt.db.QueryRow(query).Scan(&path); err != nil {
+		return "", BadQueryError{err, query, nil}
+	}
+
+	return path, nil
+}
+
+// rowQuerier is either a *sql.DB or a *sql.Tx.
+type rowQuerier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func getCurrentMigrationVersion(db rowQuerier) int {
 	var version int
 	if err := db.QueryRow(
 		`SELECT Value FROM Config WHERE Key = 'MigrationVersion' LIMIT 1`,
@@ -35,7 +174,17 @@ func getCurrentMigrationVersion(db *sql.DB) int {
 	return version
 }
 
-func doInitialMigration(db *sql.DB) error {
+func execAll(tx *sql.Tx, queries []string) error {
+	for k := range queries {
+		if err := exec(tx, queries[k]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func doInitialMigration(tx *sql.Tx) error {
 	queries := []string{
 		`CREATE TABLE "Config" (
             "Key" text NOT NULL,
@@ -51,18 +200,252 @@ func doInitialMigration(db *sql.DB) error {
             "Tags" text COLLATE 'BINARY' NOT NULL,
             PRIMARY KEY ("ID")
         );`,
+	}
+
+	return execAll(tx, queries)
+}
+
+func doRulesMigration(tx *sql.Tx) error {
+	queries := []string{
+		`CREATE TABLE "Rule" (
+            "ID" integer NOT NULL,
+            "Rule" text NOT NULL,
+            "Value" real NOT NULL,
+            "ValidFrom" integer NULL,
+            "ValidUntil" integer NULL,
+            PRIMARY KEY ("ID")
+        );`,
 
-		`INSERT INTO "Config" ("Key", "Value") VALUES (
-            'MigrationVersion', 1
-        )`,
+		`INSERT INTO "Rule" ("Rule", "Value") VALUES
+            ('HolidayFactor', 2),
+            ('OnCallFactor', 1.5)`,
 	}
 
-	for k := range queries {
-		_, err := db.Exec(queries[k])
-		if err != nil {
-			return BadQueryError{err, queries[k], nil}
-		}
+	if err := execAll(tx, queries); err != nil {
+		return err
 	}
 
-	return nil
+	// Databases already in use keep the weekly hours that used to be
+	// hardcoded, so that their reports don't lose their targets.
+	switchTo35 := time.Date(2022, 11, 14, 0, 0, 0, 0, time.Local).Unix()
+
+	return exec(
+		tx,
+		`INSERT INTO "Rule" ("Rule", "Value", "ValidFrom", "ValidUntil")
+            SELECT 'WeeklyHours', 39, NULL, ? WHERE EXISTS (SELECT 1 FROM "Task")
+            UNION ALL
+            SELECT 'WeeklyHours', 35, ?, NULL WHERE EXISTS (SELECT 1 FROM "Task")`,
+		switchTo35, switchTo35,
+	)
+}
+
+func doHolidaysMigration(tx *sql.Tx) error {
+	queries := []string{
+		`CREATE TABLE "Holiday" (
+            "ID" integer NOT NULL,
+            "Day" integer NOT NULL,
+            "Name" text NOT NULL,
+            PRIMARY KEY ("ID")
+        );`,
+
+		`CREATE UNIQUE INDEX "Holiday_Day" ON "Holiday" ("Day");`,
+	}
+
+	return execAll(tx, queries)
+}
+
+func doLeaveMigration(tx *sql.Tx) error {
+	queries := []string{
+		`CREATE TABLE "Leave" (
+            "ID" integer NOT NULL,
+            "Day" integer NOT NULL,
+            "Type" text NOT NULL,
+            "Portion" real NOT NULL,
+            PRIMARY KEY ("ID")
+        );`,
+
+		`CREATE INDEX "Leave_Day" ON "Leave" ("Day");`,
+
+		`CREATE TABLE "LeaveAllowance" (
+            "Type" text NOT NULL,
+            "DaysPerYear" real NOT NULL,
+            "MaxCarryOver" real NOT NULL,
+            PRIMARY KEY ("Type")
+        );`,
+	}
+
+	return execAll(tx, queries)
+}
+
+// doTagsMigration moves the tags from the Task.Tags JSON array to the Tag and
+// TaskTag tables, TaskTag.Position keeps the tags order.
+// Tasks with unparsable tags lose them, they can be found in the backup.
+func doTagsMigration(tx *sql.Tx) error {
+	queries := []string{
+		`CREATE TABLE "Tag" (
+            "ID" integer NOT NULL,
+            "Name" text NOT NULL,
+            PRIMARY KEY ("ID")
+        );`,
+
+		`CREATE UNIQUE INDEX "Tag_Name" ON "Tag" ("Name");`,
+
+		`CREATE TABLE "TaskTag" (
+            "TaskID" integer NOT NULL,
+            "TagID" integer NOT NULL,
+            "Position" integer NOT NULL,
+            PRIMARY KEY ("TaskID", "TagID")
+        );`,
+
+		`CREATE INDEX "TaskTag_TagID" ON "TaskTag" ("TagID");`,
+
+		`INSERT OR IGNORE INTO "Tag" ("Name")
+            SELECT DISTINCT "Tags"."value"
+            FROM "Task", json_each(IIF(json_valid("Task"."Tags"), "Task"."Tags", '[]')) AS "Tags"
+            WHERE "Tags"."type" = 'text'`,
+
+		`INSERT OR IGNORE INTO "TaskTag" ("TaskID", "TagID", "Position")
+            SELECT "Task"."ID", "Tag"."ID", "Tags"."key"
+            FROM "Task", json_each(IIF(json_valid("Task"."Tags"), "Task"."Tags", '[]')) AS "Tags"
+            JOIN "Tag" ON "Tag"."Name" = "Tags"."value"
+            WHERE "Tags"."type" = 'text'`,
+
+		`ALTER TABLE "Task" DROP COLUMN "Tags";`,
+	}
+
+	return execAll(tx, queries)
+}
+
+func doTaskStackMigration(tx *sql.Tx) error {
+	queries := []string{
+		`CREATE TABLE "TaskStack" (
+            "ID" integer NOT NULL,
+            "TaskID" integer NOT NULL,
+            PRIMARY KEY ("ID")
+        );`,
+	}
+
+	return execAll(tx, queries)
+}
+
+func doTaskDeadlineMigration(tx *sql.Tx) error {
+	queries := []string{
+		`ALTER TABLE "Task" ADD COLUMN "Deadline" integer NULL;`,
+	}
+
+	return execAll(tx, queries)
+}
+
+func doForgottenTasksMigration(tx *sql.Tx) error {
+	queries := []string{
+		`INSERT INTO "Rule" ("Rule", "Value") VALUES ('MaxTaskHours', 12)`,
+	}
+
+	return execAll(tx, queries)
+}
+
+// doJournalMigration creates the undo journal, its triggers are created by
+// updateJournalTriggers once all migrations are applied.
+func doJournalMigration(tx *sql.Tx) error {
+	queries := []string{
+		`CREATE TABLE "Journal" (
+            "ID" integer NOT NULL,
+            "CreatedAt" integer NOT NULL,
+            "Undone" integer NOT NULL DEFAULT 0,
+            PRIMARY KEY ("ID")
+        );`,
+
+		`CREATE TABLE "JournalOp" (
+            "ID" integer NOT NULL,
+            "JournalID" integer NULL,
+            "Kind" text NOT NULL,
+            "TableName" text NOT NULL,
+            "RowID" integer NOT NULL,
+            "Label" text NULL,
+            "UndoSQL" text NOT NULL,
+            "RedoSQL" text NOT NULL,
+            PRIMARY KEY ("ID")
+        );`,
+
+		`CREATE INDEX "JournalOp_JournalID" ON "JournalOp" ("JournalID");`,
+	}
+
+	return execAll(tx, queries)
+}
+
+// doTaskHistoryMigration creates the task history, existing tasks start with
+// their current state.
+func doTaskHistoryMigration(tx *sql.Tx) error {
+	queries := []string{
+		`CREATE TABLE "TaskHistory" (
+            "ID" integer NOT NULL,
+            "TaskID" integer NOT NULL,
+            "ChangedAt" integer NOT NULL,
+            "Source" text NULL,
+            "Kind" text NOT NULL,
+            "Description" text NOT NULL,
+            "StartedAt" integer NOT NULL,
+            "StoppedAt" integer NULL,
+            "Deadline" integer NULL,
+            "Tags" text NOT NULL,
+            PRIMARY KEY ("ID")
+        );`,
+
+		`CREATE INDEX "TaskHistory_TaskID" ON "TaskHistory" ("TaskID");`,
+
+		fmt.Sprintf(
+			`INSERT INTO "TaskHistory"
+                ("TaskID", "ChangedAt", "Source", "Kind", "Description", "StartedAt", "StoppedAt", "Deadline", "Tags")
+            SELECT "Task"."ID", strftime('%%s', 'now'), '%s', '%s', "Task"."Description", "Task"."StartedAt",
+                "Task"."StoppedAt", "Task"."Deadline", %s
+            FROM "Task"`,
+			SourceMigration, historyInsert, taskTagsSelect(),
+		),
+	}
+
+	return execAll(tx, queries)
+}
+
+// doSingleRunningTaskMigration stops every running task but the latest at the
+// start of the task following it, like Fsck does, so that a unique index can
+// then prevent concurrent processes from starting two tasks at once. A task
+// starting together with the latest one is stopped now rather than emptied,
+// Fsck then reports the overlap for a manual fix.
+func doSingleRunningTaskMigration(tx *sql.Tx) error {
+	const (
+		where = `WHERE "Task"."StoppedAt" IS NULL AND "Task"."ID" != (
+                SELECT "ID" FROM "Task" WHERE "StoppedAt" IS NULL ORDER BY "StartedAt" DESC, "ID" DESC LIMIT 1
+            )`
+		stop = `IFNULL((
+                SELECT MIN("Next"."StartedAt") FROM "Task" AS "Next" WHERE "Next"."StartedAt" > "Task"."StartedAt"
+            ), strftime('%s', 'now'))`
+	)
+
+	queries := []string{
+		fmt.Sprintf(
+			`INSERT INTO "TaskHistory"
+                ("TaskID", "ChangedAt", "Source", "Kind", "Description", "StartedAt", "StoppedAt", "Deadline", "Tags")
+            SELECT "Task"."ID", strftime('%%s', 'now'), '%s', '%s', "Task"."Description", "Task"."StartedAt",
+                %s, "Task"."Deadline", %s
+            FROM "Task" %s`,
+			SourceMigration, historyUpdate, stop, taskTagsSelect(), where,
+		),
+
+		fmt.Sprintf(`UPDATE "Task" SET "StoppedAt" = %s %s`, stop, where),
+
+		`CREATE UNIQUE INDEX "Task_Running" ON "Task" (("StoppedAt" IS NULL)) WHERE "StoppedAt" IS NULL;`,
+	}
+
+	return execAll(tx, queries)
+}
+
+// doTaskTimeIndexesMigration indexes the task times, which all range queries
+// filter on.
+func doTaskTimeIndexesMigration(tx *sql.Tx) error {
+	queries := []string{
+		`CREATE INDEX "Task_StartedAt" ON "Task" ("StartedAt");`,
+		`CREATE INDEX "Task_StoppedAt" ON "Task" ("StoppedAt");`,
+	}
+
+	return execAll(tx, queries)
 }
@@ -0,0 +1,218 @@
+// nolint:thelper
+package tt_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+	"tt/internal/tt"
+)
+
+func TestMigrate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tt.db")
+	app, err := tt.Open("file:" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer app.Close()
+
+	// Bring the DB to version 2 and put some data in it.
+	if err := app.MigrateTo(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.ExecSQL(`INSERT INTO Task (Description, StartedAt, Tags) VALUES ('desc', 1, '[]')`); err != nil {
+		t.Fatal(err)
+	}
+
+	cur, steps, err := app.MigrationStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cur != 2 || !steps[1].Applied || steps[2].Applied {
+		t.Fatalf("unexpected status before migration: %d %v", cur, steps)
+	}
+
+	if err := app.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if cur, _, _ := app.MigrationStatus(); cur != len(steps) {
+		t.Errorf("expected version %d, got %d", len(steps), cur)
+	}
+
+	backups, err := filepath.Glob(path + ".*.v2.bak")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 {
+		t.Fatalf("expected one backup, got %v", backups)
+	}
+
+	backup, err := tt.Open("file:" + backups[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backup.Close()
+
+	count, err := backup.QueryInt(`SELECT COUNT(*) FROM Task`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version, _, _ := backup.MigrationStatus(); count != 1 || version != 2 {
+		t.Errorf("unexpected backup content: %d tasks, version %d", count, version)
+	}
+
+	// Nothing to do, no new backup.
+	if err := app.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if backups, _ := filepath.Glob(path + ".*.bak"); len(backups) != 1 {
+		t.Errorf("expected no new backup, got %v", backups)
+	}
+}
+
+func TestRulesMigration(t *testing.T) {
+	app, err := tt.Open("file:" + filepath.Join(t.TempDir(), "tt.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer app.Close()
+
+	if err := app.MigrateTo(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.ExecSQL(`INSERT INTO Task (Description, StartedAt, StoppedAt, Tags) VALUES ('desc', 1, 2, '[]')`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		day   time.Time
+		total time.Duration
+	}{
+		{time.Date(2022, 11, 13, 0, 0, 0, 0, time.Local), 39 * time.Hour},
+		{time.Date(2022, 11, 14, 0, 0, 0, 0, time.Local), 35 * time.Hour},
+	} {
+		schedule, err := app.GetSchedule(c.day)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if schedule.Total() != c.total {
+			t.Errorf("%s: expected %s per week, got %s", c.day, c.total, schedule.Total())
+		}
+	}
+
+	// New databases have nothing to keep.
+	schedule, err := newTestApp(t).GetSchedule(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schedule.Total() != 0 {
+		t.Errorf("expected no weekly hours on a new database, got %v", schedule)
+	}
+}
+
+func TestTagsMigration(t *testing.T) {
+	app, err := tt.Open("file:" + filepath.Join(t.TempDir(), "tt.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer app.Close()
+
+	if err := app.MigrateTo(4); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.ExecSQL(`INSERT INTO Task (Description, StartedAt, StoppedAt, Tags) VALUES
+        ('a', 1, 2, '["@b","@a"]'),
+        ('b', 3, 4, '[]'),
+        ('c', 5, 6, '["@a"]'),
+        ('d', 7, 8, 'not JSON')`,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	tasks, err := app.GetTasks()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string][]string{"a": {"@b", "@a"}, "b": {}, "c": {"@a"}, "d": {}}
+	for _, task := range tasks {
+		if !reflect.DeepEqual(task.Tags, expected[task.Description]) {
+			t.Errorf("task %s: expected tags %v, got %v", task.Description, expected[task.Description], task.Tags)
+		}
+	}
+}
+
+func TestSingleRunningTaskMigration(t *testing.T) {
+	app, err := tt.Open("file:" + filepath.Join(t.TempDir(), "tt.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer app.Close()
+
+	_, steps, err := app.MigrationStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, step := range steps {
+		if step.Name == "single running task" {
+			if err := app.MigrateTo(step.Version - 1); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := app.ExecSQL(`INSERT INTO Task (ID, Description, StartedAt, StoppedAt) VALUES
+        (1, 'a', 10, NULL),
+        (2, 'b', 20, 30),
+        (3, 'c', 40, NULL),
+        (4, 'd', 40, NULL)`,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	before := time.Now().Unix()
+	if err := app.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	tasks, err := app.GetTasks()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// c starts with d, it is stopped at the time of the migration.
+	expected := map[string]int64{"a": 20, "b": 30, "c": before, "d": 0}
+	for _, task := range tasks {
+		var stop int64
+		if task.IsStopped() {
+			stop = task.StoppedAt.Unix()
+		}
+		if task.Description == "c" && stop >= before && stop <= time.Now().Unix() {
+			continue
+		}
+		if stop != expected[task.Description] {
+			t.Errorf("task %s: expected stop %d, got %d", task.Description, expected[task.Description], stop)
+		}
+	}
+
+	history, err := app.GetTaskHistory(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].Source != tt.SourceMigration || !history[0].Task.StoppedAt.Equal(tasks[len(tasks)-1].StoppedAt) {
+		t.Errorf("expected the migration in the task history, got %+v", history)
+	}
+
+	if err := app.ExecSQL(`UPDATE Task SET StoppedAt = NULL WHERE ID = 2`); err == nil {
+		t.Error("expected a second running task to be rejected")
+	}
+}
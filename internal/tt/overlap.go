@@ -0,0 +1,212 @@
+package tt
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// OverlapPolicy tells how to resolve overlaps when adding or editing a task.
+type OverlapPolicy int
+
+const (
+	OverlapReject OverlapPolicy = iota // refuse the change
+	OverlapTrim                        // shorten (or delete) the overlapped neighbours
+	OverlapShift                       // move the overlapped neighbours, keeping their duration
+)
+
+func overlapPolicyNames() []string {
+	return []string{"reject", "trim", "shift"}
+}
+
+func (p OverlapPolicy) String() string {
+	return FormatRuleValue(RuleOverlapPolicy, float64(p))
+}
+
+// ParseOverlapPolicy returns the policy with the given name.
+func ParseOverlapPolicy(str string) (OverlapPolicy, error) {
+	v, err := ParseRuleValue(RuleOverlapPolicy, str)
+	if err != nil {
+		return OverlapReject, err
+	}
+
+	if _, err := newRuleEntry(RuleOverlapPolicy, v, time.Time{}); err != nil {
+		return OverlapReject, err
+	}
+
+	return OverlapPolicy(v), nil
+}
+
+// GetOverlapPolicy returns the current OverlapPolicy rule.
+func (tt *TT) GetOverlapPolicy() (OverlapPolicy, error) {
+	var policy OverlapPolicy
+
+	if err := tt.readTransaction(func(tx *sql.Tx) error {
+		timeline, err := getRulesTimeline(tx)
+		if err != nil {
+			return err
+		}
+
+		policy = OverlapPolicy(timeline.forDay(time.Now())[RuleOverlapPolicy])
+		return nil
+	}); err != nil {
+		return OverlapReject, err
+	}
+
+	return policy, nil
+}
+
+// OverlapError is returned when a task would share some time with another.
+type OverlapError struct {
+	Task Task // the overlapped task
+}
+
+func newOverlapError(task Task) error {
+	return OverlapError{task}
+}
+
+func (e OverlapError) Error() string {
+	stop := "now"
+	if e.Task.IsStopped() {
+		stop = e.Task.StoppedAt.Format(timeFormat)
+	}
+
+	return fmt.Sprintf(
+		"the task would overlap \"%s\" (%s to %s)",
+		e.Task.Description,
+		e.Task.StartedAt.Format(timeFormat),
+		stop,
+	)
+}
+
+// Unwrap allows handling overlaps as any invalid input.
+func (e OverlapError) Unwrap() error {
+	return InvalidInputError(e.Error())
+}
+
+// TaskChange is a change made to a task to resolve an overlap.
+type TaskChange struct {
+	Before Task
+	After  *Task // nil when the task is deleted
+}
+
+// resolveOverlaps changes the tasks overlapping the given one according to
+// the policy. Shifted tasks can in turn shift their own neighbours.
+// nolint:cyclop // single loop over a queue
+func resolveOverlaps(tx *sql.Tx, task Task, policy OverlapPolicy) ([]TaskChange, error) {
+	var (
+		changes []TaskChange
+		queue   = []Task{task}
+		placed  = map[int64]bool{}
+	)
+
+	if task.ID != 0 {
+		placed[task.ID] = true
+	}
+
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+
+		overlapping, err := getOverlappingTasks(tx, cur.StartedAt, cur.end())
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range overlapping {
+			if v.ID == cur.ID || v.ID == task.ID {
+				continue
+			}
+
+			if placed[v.ID] {
+				return nil, newOverlapError(v) // would go back and forth
+			}
+
+			var after *Task
+			switch policy {
+			case OverlapTrim:
+				after, err = trimTask(v, cur.StartedAt, cur.end())
+			case OverlapShift:
+				after, err = shiftTask(v, cur.StartedAt, cur.end())
+			default:
+				err = newOverlapError(v)
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			if after == nil {
+				err = deleteTask(tx, v.ID)
+			} else {
+				err = after.save(tx)
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			changes = append(changes, TaskChange{Before: v, After: after})
+			placed[v.ID] = true
+			if after != nil && policy == OverlapShift {
+				queue = append(queue, *after)
+			}
+		}
+	}
+
+	return changes, nil
+}
+
+// trimTask shortens the task so it does not overlap start-end, it returns nil
+// if nothing is left of the task. A task containing start-end cannot be
+// trimmed without losing one of its ends.
+func trimTask(v Task, start, end time.Time) (*Task, error) {
+	ret := v
+
+	switch {
+	case v.StartedAt.Before(start) && v.end().After(end):
+		return nil, newOverlapError(v)
+	case v.StartedAt.Before(start):
+		if !v.IsStopped() {
+			return nil, newOverlapError(v)
+		}
+		ret.StoppedAt = start
+	case v.end().After(end):
+		ret.StartedAt = end
+	default:
+		return nil, nil
+	}
+
+	return &ret, nil
+}
+
+// shiftTask moves the task out of start-end, before it if the task started
+// first and after it otherwise.
+func shiftTask(v Task, start, end time.Time) (*Task, error) {
+	ret := v
+
+	if v.StartedAt.Before(start) {
+		if !v.IsStopped() {
+			return nil, newOverlapError(v)
+		}
+
+		d := v.StoppedAt.Sub(start)
+		ret.StartedAt = v.StartedAt.Add(-d)
+		ret.StoppedAt = v.StoppedAt.Add(-d)
+
+		return &ret, nil
+	}
+
+	d := end.Sub(v.StartedAt)
+	ret.StartedAt = v.StartedAt.Add(d)
+	if v.IsStopped() {
+		ret.StoppedAt = v.StoppedAt.Add(d)
+	}
+
+	if ret.end().After(time.Now()) || ret.StartedAt.After(time.Now()) {
+		return nil, InvalidInputError(fmt.Sprintf(
+			"cannot shift \"%s\" past the current time",
+			v.Description,
+		))
+	}
+
+	return &ret, nil
+}
@@ -0,0 +1,137 @@
+// nolint:thelper
+package tt_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"tt/internal/tt"
+)
+
+func addOverlapTestTasks(t *testing.T, app *tt.TT, day time.Time) []tt.Task {
+	return addTestTasks(
+		t, app, day,
+		testTask{"first", 9 * time.Hour, 10 * time.Hour},
+		testTask{"second", 10 * time.Hour, 11 * time.Hour},
+		testTask{"third", 11 * time.Hour, 12 * time.Hour},
+	)
+}
+
+func TestUpdateTaskOverlaps(t *testing.T) {
+	app := newTestApp(t)
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.Local)
+	tasks := addOverlapTestTasks(t, app, day)
+
+	edited := tasks[1]
+	edited.StartedAt = day.Add(9*time.Hour + 30*time.Minute)
+
+	var overlapErr tt.OverlapError
+	if err := app.UpdateTask(edited); !errors.As(err, &overlapErr) || overlapErr.Task.ID != tasks[0].ID {
+		t.Fatalf("expected an overlap with the first task, got %v", err)
+	}
+	if !isInvalidInput(app.UpdateTask(edited)) {
+		t.Error("expected overlaps to be invalid input")
+	}
+
+	// A dry run reports the changes without saving anything.
+	changes, err := app.UpdateTaskWith(edited, tt.OverlapTrim, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || !changes[0].After.StoppedAt.Equal(edited.StartedAt) {
+		t.Fatalf("unexpected changes: %+v", changes)
+	}
+	if task, err := app.GetTask(tasks[0].ID); err != nil || !task.StoppedAt.Equal(tasks[0].StoppedAt) {
+		t.Fatalf("expected the dry run to change nothing, got %+v, %v", task, err)
+	}
+
+	if _, err := app.UpdateTaskWith(edited, tt.OverlapTrim, false); err != nil {
+		t.Fatal(err)
+	}
+	if task, err := app.GetTask(tasks[0].ID); err != nil || !task.StoppedAt.Equal(edited.StartedAt) {
+		t.Fatalf("expected the first task to be trimmed, got %+v, %v", task, err)
+	}
+}
+
+func TestTrimDeletesCoveredTasks(t *testing.T) {
+	app := newTestApp(t)
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.Local)
+	tasks := addOverlapTestTasks(t, app, day)
+
+	_, changes, err := app.AddTaskWith("meeting", day.Add(9*time.Hour+30*time.Minute), day.Add(11*time.Hour+30*time.Minute), tt.OverlapTrim, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 3 {
+		t.Fatalf("expected 3 changes, got %+v", changes)
+	}
+
+	if _, err := app.GetTask(tasks[1].ID); !errors.Is(err, tt.ErrInvalidTaskID) {
+		t.Errorf("expected the covered task to be deleted, got %v", err)
+	}
+
+	third, err := app.GetTask(tasks[2].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := day.Add(11*time.Hour + 30*time.Minute); !third.StartedAt.Equal(expected) || !third.StoppedAt.Equal(tasks[2].StoppedAt) {
+		t.Errorf("expected the third task to start at %s, got %+v", expected, third)
+	}
+}
+
+func TestTrimRejectsContainingTask(t *testing.T) {
+	app := newTestApp(t)
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.Local)
+
+	work, err := app.AddTask("work", day.Add(9*time.Hour), day.Add(18*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = app.AddTaskWith("meeting", day.Add(14*time.Hour), day.Add(15*time.Hour), tt.OverlapTrim, false)
+	var overlapErr tt.OverlapError
+	if !errors.As(err, &overlapErr) || overlapErr.Task.ID != work.ID {
+		t.Fatalf("expected an overlap with the containing task, got %v", err)
+	}
+
+	if task, err := app.GetTask(work.ID); err != nil || !task.StoppedAt.Equal(work.StoppedAt) {
+		t.Errorf("expected the containing task to be left as is, got %+v, %v", task, err)
+	}
+}
+
+func TestShiftCascades(t *testing.T) {
+	app := newTestApp(t)
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.Local)
+	tasks := addOverlapTestTasks(t, app, day)
+
+	edited := tasks[0]
+	edited.StoppedAt = day.Add(10*time.Hour + 15*time.Minute)
+
+	changes, err := app.UpdateTaskWith(edited, tt.OverlapShift, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %+v", changes)
+	}
+
+	for _, v := range tasks[1:] {
+		task, err := app.GetTask(v.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !task.StartedAt.Equal(v.StartedAt.Add(15*time.Minute)) || task.Duration() != v.Duration() {
+			t.Errorf("expected %s to be shifted by 15m, got %+v", v.Description, task)
+		}
+	}
+
+	// Shifting a task into the future is refused.
+	last := time.Now().Add(-30 * time.Minute).Truncate(time.Second)
+	if _, err := app.AddTask("recent", last, last.Add(20*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := app.AddTaskWith("before", last.Add(-time.Hour), last.Add(15*time.Minute), tt.OverlapShift, false); !isInvalidInput(err) {
+		t.Errorf("expected an invalid input error, got %v", err)
+	}
+}
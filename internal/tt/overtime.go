@@ -0,0 +1,198 @@
+package tt
+
+import (
+	"database/sql"
+	"time"
+)
+
+// OvertimeStrategy computes the rule-computed durations of a report from its
+// raw durations and targets.
+// The report engine calls Day for every day, then Week once for every week
+// with the days of that week, weeks at the edges of the report can be
+// partial.
+type OvertimeStrategy interface {
+	Day(e *ReportEntry)
+	Week(days []ReportEntry)
+}
+
+// overtimeStrategies holds the built-in strategies, the index is the value of
+// the OvertimeStrategy rule.
+var overtimeStrategies = []struct {
+	name     string
+	strategy OvertimeStrategy
+}{
+	{"daily", dailyStrategy{}},
+	{"weekly", weeklyStrategy{}},
+	{"french", frenchStrategy{}},
+}
+
+func overtimeStrategyNames() []string {
+	ret := make([]string, 0, len(overtimeStrategies))
+	for _, v := range overtimeStrategies {
+		ret = append(ret, v.name)
+	}
+
+	return ret
+}
+
+// overtimeStrategy returns the strategy to apply, the daily strategy being
+// the default.
+func (rules rulesSnapshot) overtimeStrategy() OvertimeStrategy {
+	i := int(rules[RuleOvertimeStrategy])
+	if i < 0 || i >= len(overtimeStrategies) {
+		i = 0
+	}
+
+	return overtimeStrategies[i].strategy
+}
+
+// isCounted returns true if the day counts towards overtime.
+func (e ReportEntry) isCounted() bool {
+	return e.Target > 0
+}
+
+// Values of the MissingDayPolicy rule, how to count workdays without
+// anything recorded.
+const (
+	missingDayDone    = iota // as if the target was done
+	missingDayDeficit        // the whole target is counted as time taken
+	missingDayLeave          // as a day off, the target is waived
+)
+
+func missingDayPolicyNames() []string {
+	return []string{"done", "deficit", "leave"}
+}
+
+// isMissing returns true for workdays without anything recorded.
+func (e ReportEntry) isMissing() bool {
+	return e.Target > 0 && e.WorkDuration == 0 && e.OffDuration == 0
+}
+
+// isMissingDay returns whether nothing was recorded on the given day, as the
+// report sees it.
+func isMissingDay(tx *sql.Tx, day time.Time, rules rulesSnapshot, off daysOff) (bool, error) {
+	next := day.AddDate(0, 0, 1)
+
+	tasks, err := getTasksInRange(tx, day, next)
+	if err != nil {
+		return false, err
+	}
+
+	return newReportEntry(day, clampTasks(tasks, day, next), rules, off).isMissing(), nil
+}
+
+// applyMissingDayPolicy updates the target and credited time of a workday
+// without anything recorded. Days that are not over yet are not missing,
+// their target is waived.
+func applyMissingDayPolicy(e *ReportEntry, rules rulesSnapshot, inProgress bool) {
+	if !e.isMissing() {
+		return
+	}
+
+	if inProgress {
+		e.Target = 0
+		return
+	}
+
+	switch int(rules[RuleMissingDayPolicy]) {
+	case missingDayDone:
+		e.OffDuration += e.Target
+	case missingDayDeficit:
+		break // counted as is
+	case missingDayLeave:
+		e.Target = 0
+	}
+}
+
+// setDelta stores the difference between the time done and the target.
+func setDelta(e *ReportEntry, delta time.Duration) {
+	if delta > 0 {
+		e.Overtime += delta
+	} else if delta < 0 {
+		e.Taken += -delta
+	}
+}
+
+// weekTotals returns the time done and the target of all counted days of a
+// week, and the index of the last of these days.
+func weekTotals(days []ReportEntry) (done, target time.Duration, last int) {
+	last = -1
+	for i, v := range days {
+		if !v.isCounted() {
+			continue
+		}
+
+		done += v.WorkDuration + v.OffDuration
+		target += v.Target
+		last = i
+	}
+
+	return done, target, last
+}
+
+// dailyStrategy counts everything done over the daily target as overtime.
+type dailyStrategy struct{}
+
+func (dailyStrategy) Day(e *ReportEntry) {
+	if !e.isCounted() {
+		return
+	}
+
+	setDelta(e, (e.WorkDuration+e.OffDuration)-e.Target)
+}
+
+func (dailyStrategy) Week([]ReportEntry) {}
+
+// weeklyStrategy counts everything done over the weekly target as overtime.
+// The result is stored on the last counted day of the week.
+type weeklyStrategy struct{}
+
+func (weeklyStrategy) Day(*ReportEntry) {}
+
+func (weeklyStrategy) Week(days []ReportEntry) {
+	done, target, last := weekTotals(days)
+	if last < 0 {
+		return
+	}
+
+	setDelta(&days[last], done-target)
+}
+
+// frenchStrategy implements the French legal overtime bands: the first 8
+// hours over the weekly target (35h to 43h for a full time contract) are
+// counted with a 25% increase, the next ones with a 50% increase.
+// The result is stored on the last counted day of the week.
+type frenchStrategy struct{}
+
+const (
+	frenchFirstBandDuration = 8 * time.Hour
+	frenchFirstBandFactor   = 1.25
+	frenchSecondBandFactor  = 1.5
+)
+
+func (frenchStrategy) Day(*ReportEntry) {}
+
+func (frenchStrategy) Week(days []ReportEntry) {
+	done, target, last := weekTotals(days)
+	if last < 0 {
+		return
+	}
+
+	delta := done - target
+	if delta <= 0 {
+		setDelta(&days[last], delta)
+		return
+	}
+
+	first := delta
+	if first > frenchFirstBandDuration {
+		first = frenchFirstBandDuration
+	}
+	second := delta - first
+
+	setDelta(
+		&days[last],
+		time.Duration(float64(first)*frenchFirstBandFactor)+
+			time.Duration(float64(second)*frenchSecondBandFactor),
+	)
+}
@@ -0,0 +1,83 @@
+package tt_test
+
+import (
+	"testing"
+	"time"
+	"tt/internal/tt"
+)
+
+func newTestWeek(work ...time.Duration) []tt.ReportEntry {
+	monday := time.Date(2022, 11, 14, 0, 0, 0, 0, time.Local)
+	days := make([]tt.ReportEntry, 0, len(work))
+
+	for i, v := range work {
+		e := tt.ReportEntry{Day: monday.AddDate(0, 0, i), WorkDuration: v}
+		if i < 5 && v > 0 {
+			e.Target = 7 * time.Hour
+		}
+
+		days = append(days, e)
+	}
+
+	return days
+}
+
+func sumWeek(strategy tt.OvertimeStrategy, days []tt.ReportEntry) tt.ReportEntry {
+	for i := range days {
+		strategy.Day(&days[i])
+	}
+	strategy.Week(days)
+
+	var acc tt.ReportEntry
+	for _, v := range days {
+		acc.Add(v)
+	}
+
+	return acc
+}
+
+func TestOvertimeStrategies(t *testing.T) {
+	h := time.Hour
+	cases := []struct {
+		name            string
+		strategy        string
+		work            []time.Duration
+		overtime, taken time.Duration
+	}{
+		{"daily even", "daily", []time.Duration{8 * h, 6 * h, 7 * h, 7 * h, 7 * h}, 1 * h, 1 * h},
+		{"weekly even", "weekly", []time.Duration{8 * h, 6 * h, 7 * h, 7 * h, 7 * h}, 0, 0},
+		{"weekly over", "weekly", []time.Duration{9 * h, 6 * h, 7 * h, 7 * h, 7 * h}, 1 * h, 0},
+		{"weekly partial", "weekly", []time.Duration{6 * h, 6 * h}, 0, 2 * h},
+		{"french under", "french", []time.Duration{7 * h, 7 * h, 7 * h, 7 * h, 6 * h}, 0, 1 * h},
+		{"french first band", "french", []time.Duration{9 * h, 9 * h, 7 * h, 7 * h, 7 * h}, 5 * h, 0},
+		{
+			"french second band", "french",
+			[]time.Duration{10 * h, 10 * h, 10 * h, 9 * h, 6 * h},
+			8*h*125/100 + 2*h*150/100, 0,
+		},
+		{"weekend ignored", "weekly", []time.Duration{7 * h, 7 * h, 7 * h, 7 * h, 7 * h, 5 * h}, 0, 0},
+	}
+
+	for _, c := range cases {
+		days := newTestWeek(c.work...)
+		acc := sumWeek(tt.GetOvertimeStrategy(c.strategy), days)
+
+		if acc.Overtime != c.overtime {
+			t.Errorf("%s: expected %s overtime, got %s", c.name, c.overtime, acc.Overtime)
+		}
+		if acc.Taken != c.taken {
+			t.Errorf("%s: expected %s taken, got %s", c.name, c.taken, acc.Taken)
+		}
+		if c.strategy == "daily" {
+			continue
+		}
+
+		// Weekly strategies store their result on the last day with a target.
+		_, _, last := tt.WeekTotals(days)
+		for i, v := range days {
+			if i != last && (v.Overtime != 0 || v.Taken != 0) {
+				t.Errorf("%s: expected the weekly result on day #%d, got some on day #%d", c.name, last, i)
+			}
+		}
+	}
+}
@@ -15,8 +15,14 @@ type ReportEntry struct {
 	// Raw total durations disregarding any rules
 	WorkDuration   time.Duration
 	OnCallDuration time.Duration
+	// Not counted as work time but doesn't create an overtime deficit, eg.
+	// paid half-day leave.
+	OffDuration time.Duration
 
 	// {{{ Rule-computed.
+	// Expected work time.
+	Target time.Duration
+
 	// Either paid or given back as 1:1 time off.
 	Overtime time.Duration
 
@@ -42,6 +48,8 @@ func (e *ReportEntry) Add(v ReportEntry) {
 
 	e.WorkDuration += v.WorkDuration
 	e.OnCallDuration += v.OnCallDuration
+	e.OffDuration += v.OffDuration
+	e.Target += v.Target
 	e.Overtime += v.Overtime
 	e.Taken += v.Taken
 	e.InLieu += v.InLieu
@@ -57,15 +65,13 @@ const (
 )
 
 //nolint: cyclop
-func newReportEntry(day time.Time, tasks []Task, rules rulesSnapshot) ReportEntry {
+func newReportEntry(day time.Time, tasks []Task, rules rulesSnapshot, off daysOff) ReportEntry {
 	e := ReportEntry{Day: day}
-	// not counted as work time but doesn't create an overtime deficit, eg. paid half-day leave
-	var offTime time.Duration
 
 	for _, task := range tasks {
 		switch {
 		case task.HasTag(tagOff):
-			offTime += task.Duration()
+			e.OffDuration += task.Duration()
 		case task.HasTag(tagOnCall):
 			e.OnCallDuration += task.Duration()
 		default:
@@ -83,25 +89,36 @@ func newReportEntry(day time.Time, tasks []Task, rules rulesSnapshot) ReportEntr
 		}
 	}
 
-	if isOffDay(day.Weekday()) {
-		e.InLieu += time.Duration(float64(e.WorkDuration) * rules[ruleHolidayFactor])
-	} else if e.WorkDuration > 0 { // non-worked weekdays are considered off
-		dailyWork := time.Hour * time.Duration((rules[ruleWeeklyHours])) / 5
-		delta := (e.WorkDuration + offTime) - dailyWork
-		if delta > 0 {
-			e.Overtime += delta
-		} else if delta < 0 {
-			e.Taken += -delta
-		}
+	if off.holidays.contains(day) || rules.isOffDay(day.Weekday()) {
+		e.InLieu += time.Duration(float64(e.WorkDuration) * rules[RuleHolidayFactor])
+	} else {
+		e.Target = rules.schedule().Weekday(day.Weekday())
+		e.OffDuration += off.leaves.credit(day, e.Target)
 	}
 
-	e.InLieu += time.Duration(float64(e.OnCallDuration) * rules[ruleOnCallFactor])
+	e.InLieu += time.Duration(float64(e.OnCallDuration) * rules[RuleOnCallFactor])
 
 	return e
 }
 
-func isOffDay(wd time.Weekday) bool {
-	return wd == time.Saturday || wd == time.Sunday
+// daysOff holds the days off that are not part of the schedule.
+type daysOff struct {
+	holidays holidaySet
+	leaves   leaveSet
+}
+
+func getDaysOff(tx *sql.Tx, start, end time.Time) (daysOff, error) {
+	holidays, err := getHolidaySet(tx, start, end)
+	if err != nil {
+		return daysOff{}, fmt.Errorf("unable to fetch holidays: %w", err)
+	}
+
+	leaves, err := getLeaveSet(tx, start, end)
+	if err != nil {
+		return daysOff{}, fmt.Errorf("unable to fetch leave: %w", err)
+	}
+
+	return daysOff{holidays, leaves}, nil
 }
 
 type Report struct {
@@ -128,7 +145,7 @@ func (tt *TT) GetReport() (Report, error) {
 		return Report{}, fmt.Errorf("unable to fetch first task: %w", err)
 	}
 
-	err = tt.transaction(func(tx *sql.Tx) (err error) {
+	err = tt.readTransaction(func(tx *sql.Tx) (err error) {
 		report, err = tt.getReportTx(tx, firstTask.StartedAt, time.Now())
 		return err
 	})
@@ -136,54 +153,114 @@ func (tt *TT) GetReport() (Report, error) {
 	return report, err
 }
 
-func (tt *TT) getReportTx(tx *sql.Tx, start, end time.Time) (Report, error) {
+// getReportTx reads the tasks of the range once, in order, and splits them on
+// each day.
+func (tt *TT) getReportTx(tx *sql.Tx, start, end time.Time) (report Report, err error) {
+	day := util.GetStartOfDay(start)
+
+	timeline, err := getRulesTimeline(tx)
+	if err != nil {
+		return Report{}, fmt.Errorf("unable to fetch rules: %w", err)
+	}
+
+	off, err := getDaysOff(tx, start, end)
+	if err != nil {
+		return Report{}, err
+	}
+
+	tasks, err := streamTasksInRange(tx, day, end)
+	if err != nil {
+		return Report{}, fmt.Errorf("unable to fetch tasks for range %s-%s: %w", day, end, err)
+	}
+	defer func() {
+		if err2 := tasks.Close(); err == nil && err2 != nil {
+			report, err = Report{}, err2
+		}
+	}()
+
 	var (
-		report   Report
-		timeline = staticRulesTimeline()
-		day      = util.GetStartOfDay(start)
+		rules   = timeline.cursor()
+		days    = int(end.Sub(day).Hours()/24) + 1
+		ongoing []Task // tasks that may go on after the current day
 	)
 
+	report.Daily = make([]ReportEntry, 0, days)
+
 	for day.Before(end) {
 		var (
-			rules   = timeline.forDay(day)
-			nextDay = day.AddDate(0, 0, 1)
+			dayRules = rules.forDay(day)
+			nextDay  = day.AddDate(0, 0, 1)
 		)
 
-		tasks, err := getTasksInRange(tx, day, nextDay)
+		started, err := tasks.startingBefore(nextDay)
 		if err != nil {
 			return Report{}, fmt.Errorf("unable to fetch tasks for range %s-%s: %w", day, nextDay, err)
 		}
-
-		report.Daily = append(report.Daily, newReportEntry(
-			day,
-			clampTasks(tasks, day, nextDay),
-			rules,
-		))
+		ongoing = append(ongoing, started...)
+
+		entry := newReportEntry(day, clampTasks(ongoing, day, nextDay), dayRules, off)
+		applyMissingDayPolicy(&entry, dayRules, nextDay.After(end))
+		dayRules.overtimeStrategy().Day(&entry)
+		report.Daily = append(report.Daily, entry)
+
+		// Only keep the tasks still running on the next day.
+		kept := ongoing[:0]
+		for _, v := range ongoing {
+			if !v.IsStopped() || v.StoppedAt.After(nextDay) {
+				kept = append(kept, v)
+			}
+		}
+		ongoing = kept
 
 		day = nextDay
 	}
 
+	applyWeeklyStrategies(report.Daily, timeline)
 	report.computeAggregates()
 
 	return report, nil
 }
 
-// Remove tasks or cut them if they don't fit in the given start/end.
+// applyWeeklyStrategies calls the overtime strategy of every week with its
+// days, the strategy being the one applicable on the first day of the week.
+func applyWeeklyStrategies(days []ReportEntry, timeline rulesTimeline) {
+	rules := timeline.cursor()
+
+	for start := 0; start < len(days); {
+		var (
+			weekStart = util.GetStartOfWeek(days[start].Day)
+			end       = start + 1
+		)
+
+		for end < len(days) && util.GetStartOfWeek(days[end].Day).Equal(weekStart) {
+			end++
+		}
+
+		rules.forDay(days[start].Day).overtimeStrategy().Week(days[start:end])
+		start = end
+	}
+}
+
+// Remove tasks or cut them if they don't fit in the given start/end. Running
+// tasks are stopped at the end if it is past.
 func clampTasks(tasks []Task, start, end time.Time) []Task {
 	ret := make([]Task, 0, len(tasks))
+	now := time.Now()
 
 	for _, task := range tasks {
-		if task.StartedAt.After(end) {
+		if !task.StartedAt.Before(end) {
+			continue
+		}
+
+		if task.IsStopped() && !task.StoppedAt.After(start) {
 			continue
 		}
 
 		if task.StartedAt.Before(start) {
-			if !task.StoppedAt.IsZero() && task.StoppedAt.Before(start) {
-				continue
-			}
+			task.StartedAt = start
 		}
 
-		if task.StoppedAt.After(end) {
+		if task.StoppedAt.After(end) || (!task.IsStopped() && end.Before(now)) {
 			task.StoppedAt = end
 		}
 
@@ -193,17 +270,53 @@ func clampTasks(tasks []Task, start, end time.Time) []Task {
 	return ret
 }
 
+// GetTagReport returns the total time spent on each tag.
 func (tt *TT) GetTagReport() (map[string]time.Duration, error) {
 	ret := map[string]time.Duration{}
-	tasks, err := tt.GetTasks()
-	if err != nil {
-		return nil, fmt.Errorf("unable to fetch tasks: %w", err)
-	}
 
-	for _, task := range tasks {
-		for _, tag := range task.Tags {
-			ret[tag] += task.Duration()
+	if err := tt.readTransaction(func(tx *sql.Tx) error {
+		var hasTasks bool
+		existsQuery := `SELECT EXISTS (SELECT 1 FROM Task)`
+		if err := tx.QueryRow(existsQuery).Scan(&hasTasks); err != nil {
+			return BadQueryError{err, existsQuery, nil}
+		}
+		if !hasTasks {
+			return fmt.Errorf("unable to fetch tasks: %w", ErrNoTasks)
+		}
+
+		query := `
+            SELECT Tag.Name, SUM(IFNULL(Task.StoppedAt, ?) - Task.StartedAt)
+            FROM TaskTag
+            JOIN Tag ON Tag.ID = TaskTag.TagID
+            JOIN Task ON Task.ID = TaskTag.TaskID
+            GROUP BY Tag.ID`
+		now := time.Now().Unix()
+
+		rows, err := tx.Query(query, now)
+		if err != nil {
+			return BadQueryError{err, query, []interface{}{now}}
 		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				tag     string
+				seconds int64
+			)
+			if err := rows.Scan(&tag, &seconds); err != nil {
+				return BadQueryError{err, query, []interface{}{now}}
+			}
+
+			ret[tag] = time.Duration(seconds) * time.Second
+		}
+
+		if err := rows.Err(); err != nil {
+			return BadQueryError{err, query, []interface{}{now}}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
 	}
 
 	return ret, nil
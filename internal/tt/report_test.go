@@ -0,0 +1,318 @@
+// nolint:thelper
+package tt_test
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+	"tt/internal/tt"
+)
+
+// insertTestTasks inserts stopped tasks given as start/stop pairs.
+func insertTestTasks(t *testing.T, app *tt.TT, tags []string, times ...time.Time) {
+	tasks := make([]tt.Task, 0, len(times)/2)
+	for i := 0; i+1 < len(times); i += 2 {
+		tasks = append(tasks, tt.Task{Description: "test", Tags: tags, StartedAt: times[i], StoppedAt: times[i+1]})
+	}
+
+	if err := app.InsertTasks(tasks); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func getTestReport(t *testing.T, app *tt.TT, start, end time.Time) tt.Report {
+	report, err := app.GetReportIn(start, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return report
+}
+
+func TestReportHolidays(t *testing.T) {
+	app := newTestApp(t)
+
+	if _, err := app.AddRule(tt.RuleWeeklyHours, 35, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Whit Monday 2024 worked for 2h, the rest of the week done by the book.
+	monday := time.Date(2024, 5, 20, 0, 0, 0, 0, time.Local)
+	insertTestTasks(t, app, nil,
+		monday.Add(9*time.Hour), monday.Add(11*time.Hour),
+		monday.Add(33*time.Hour), monday.Add(40*time.Hour),
+	)
+
+	report := getTestReport(t, app, monday, monday.AddDate(0, 0, 2))
+	if len(report.Daily) != 2 {
+		t.Fatalf("expected 2 days, got %d", len(report.Daily))
+	}
+
+	if report.Daily[0].Taken != 5*time.Hour || report.Daily[0].Overtime != 0 {
+		t.Errorf("expected Monday to be a regular day before the calendar is set, got %+v", report.Daily[0])
+	}
+
+	if err := app.SetHolidayCalendar("fr"); err != nil {
+		t.Fatal(err)
+	}
+
+	report = getTestReport(t, app, monday, monday.AddDate(0, 0, 2))
+	if v := report.Daily[0]; v.Target != 0 || v.Overtime != 0 || v.Taken != 0 || v.InLieu != 4*time.Hour {
+		t.Errorf("expected Monday to be a worked holiday, got %+v", v)
+	}
+	if v := report.Daily[1]; v.Target != 7*time.Hour || v.Overtime != 0 || v.Taken != 0 {
+		t.Errorf("expected Tuesday to be a regular day, got %+v", v)
+	}
+}
+
+func TestReportLeave(t *testing.T) {
+	app := newTestApp(t)
+
+	if _, err := app.AddRule(tt.RuleWeeklyHours, 35, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	monday := time.Date(2024, 3, 4, 0, 0, 0, 0, time.Local)
+	insertTestTasks(t, app, nil, monday.Add(9*time.Hour), monday.Add(13*time.Hour))
+
+	if _, err := app.AddLeave(monday, monday, tt.LeavePaid, 0.5); err != nil {
+		t.Fatal(err)
+	}
+
+	report := getTestReport(t, app, monday, monday.AddDate(0, 0, 1))
+	if v := report.Daily[0]; v.OffDuration != 3*time.Hour+30*time.Minute || v.Overtime != 30*time.Minute || v.Taken != 0 {
+		t.Errorf("expected the half-day leave to be credited, got %+v", v)
+	}
+}
+
+func TestReportMissingDayPolicy(t *testing.T) {
+	app := newTestApp(t)
+
+	if _, err := app.AddRule(tt.RuleWeeklyHours, 35, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing recorded on Wednesday.
+	monday := time.Date(2024, 3, 4, 0, 0, 0, 0, time.Local)
+	for _, i := range []int{0, 1, 3, 4} {
+		day := monday.AddDate(0, 0, i)
+		insertTestTasks(t, app, nil, day.Add(9*time.Hour), day.Add(16*time.Hour))
+	}
+
+	cases := []struct {
+		policy        string
+		target, taken time.Duration
+	}{
+		{"done", 35 * time.Hour, 0},
+		{"deficit", 35 * time.Hour, 7 * time.Hour},
+		{"leave", 28 * time.Hour, 0},
+	}
+
+	for _, c := range cases {
+		value, err := tt.ParseRuleValue(tt.RuleMissingDayPolicy, c.policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		entry, err := app.AddRule(tt.RuleMissingDayPolicy, value, time.Time{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		report := getTestReport(t, app, monday, monday.AddDate(0, 0, 7))
+		if v := report.Accumulated; v.Target != c.target || v.Taken != c.taken || v.Overtime != 0 {
+			t.Errorf("%s: expected %s target and %s taken, got %+v", c.policy, c.target, c.taken, v)
+		}
+
+		// An undated rule would only start today with this one in place.
+		if err := app.DeleteRule(entry.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The day in progress is not missing yet.
+	report := getTestReport(t, app, monday, monday.Add(60*time.Hour))
+	if v := report.Daily[2]; v.Target != 0 || v.Taken != 0 {
+		t.Errorf("expected the day in progress to be waived, got %+v", v)
+	}
+}
+
+func TestDurationLeftMissingDay(t *testing.T) {
+	app := newTestApp(t)
+
+	if _, err := app.AddRule(tt.RuleWeeklyHours, 35, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only on call on Monday, which the report counts as missing.
+	monday := time.Date(2024, 3, 4, 0, 0, 0, 0, time.Local)
+	insertTestTasks(t, app, []string{"@oncall"}, monday.Add(9*time.Hour), monday.Add(16*time.Hour))
+	tuesday := monday.AddDate(0, 0, 1)
+	insertTestTasks(t, app, nil, tuesday.Add(9*time.Hour), tuesday.Add(16*time.Hour))
+
+	// Missing days are done by default, their target is credited.
+	if v := getTestReport(t, app, monday, tuesday).Daily[0]; v.OffDuration != v.Target {
+		t.Fatalf("expected the report to count Monday as missing, got %+v", v)
+	}
+
+	_, weekly, err := app.GetDurationLeftAt(monday.AddDate(0, 0, 2).Add(8 * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 35h - 7h targeted, 14h recorded.
+	if weekly != 14*time.Hour {
+		t.Errorf("expected 14h left this week, got %s", weekly)
+	}
+}
+
+func TestTagReport(t *testing.T) {
+	app := newTestApp(t)
+
+	start := time.Date(2024, 3, 4, 9, 0, 0, 0, time.Local)
+	if _, err := app.GetTagReport(); !errors.Is(err, tt.ErrNoTasks) {
+		t.Errorf("expected tt.ErrNoTasks on an empty database, got %v", err)
+	}
+
+	insertTestTasks(t, app, []string{"@acme", "@meeting"}, start, start.Add(time.Hour))
+	insertTestTasks(t, app, []string{"@acme"}, start.Add(2*time.Hour), start.Add(4*time.Hour))
+	insertTestTasks(t, app, nil, start.Add(5*time.Hour), start.Add(6*time.Hour))
+
+	report, err := app.GetTagReport()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]time.Duration{"@acme": 3 * time.Hour, "@meeting": time.Hour}
+	if !reflect.DeepEqual(report, expected) {
+		t.Errorf("expected %v, got %v", expected, report)
+	}
+}
+
+// insertSyntheticTasks inserts consecutive tasks over the given number of days
+// from start: a few per workday, some on call or off, and now and then one
+// lasting up to three days.
+func insertSyntheticTasks(t testing.TB, app *tt.TT, rnd *rand.Rand, start time.Time, days int) {
+	var (
+		tags  = [][]string{nil, nil, nil, {"@acme"}, {"@oncall"}, {"@off"}}
+		tasks []tt.Task
+	)
+
+	for day := 0; day < days; day++ {
+		at := start.AddDate(0, 0, day).Add(time.Duration(7*60+rnd.Intn(180)) * time.Minute)
+		if weekday := at.Weekday(); (weekday == time.Saturday || weekday == time.Sunday) && rnd.Intn(10) > 0 {
+			continue
+		}
+
+		for i := rnd.Intn(6); i >= 0; i-- {
+			length := time.Duration(15+rnd.Intn(180)) * time.Minute
+			if rnd.Intn(100) == 0 {
+				length = time.Duration(rnd.Intn(72)) * time.Hour
+			}
+
+			task := tt.Task{Description: "task", Tags: tags[rnd.Intn(len(tags))], StartedAt: at, StoppedAt: at.Add(length)}
+			tasks = append(tasks, task)
+
+			at = task.StoppedAt.Add(time.Duration(rnd.Intn(60)) * time.Minute)
+		}
+
+		if next := start.AddDate(0, 0, day+1); at.After(next) {
+			day += int(at.Sub(next).Hours()/24) + 1
+		}
+	}
+
+	if err := app.InsertTasks(tasks); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReportEquivalence(t *testing.T) {
+	app := newTestApp(t)
+	rnd := rand.New(rand.NewSource(1)) // nolint:gosec
+	start := time.Date(2023, 1, 2, 0, 0, 0, 0, time.Local)
+
+	insertSyntheticTasks(t, app, rnd, start, 400)
+
+	french, err := tt.ParseRuleValue(tt.RuleOvertimeStrategy, "french")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []struct {
+		rule  tt.Rule
+		value float64
+		from  time.Time
+	}{
+		{tt.RuleWeeklyHours, 39, time.Time{}},
+		{tt.RuleHolidayFactor, 1.5, time.Time{}},
+		{tt.RuleWeeklyHours, 35, time.Date(2023, 3, 15, 0, 0, 0, 0, time.Local)},
+		{tt.RuleOvertimeStrategy, french, time.Date(2023, 6, 1, 0, 0, 0, 0, time.Local)},
+		{tt.RuleWednesdayHours, 0, time.Date(2023, 9, 6, 0, 0, 0, 0, time.Local)},
+	} {
+		if _, err := app.AddRule(v.rule, v.value, v.from); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := app.AddHoliday(time.Date(2023, 5, 1, 0, 0, 0, 0, time.Local), "Labour Day"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.AddLeave(time.Date(2023, 8, 7, 0, 0, 0, 0, time.Local), time.Date(2023, 8, 18, 0, 0, 0, 0, time.Local), tt.LeavePaid, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	ranges := [][2]time.Time{
+		{start, start.AddDate(0, 0, 420)},
+		{start.Add(-36 * time.Hour), start.AddDate(0, 2, 0)},
+		{time.Date(2023, 4, 12, 15, 0, 0, 0, time.Local), time.Date(2023, 6, 3, 10, 0, 0, 0, time.Local)},
+	}
+	for _, r := range ranges {
+		expected, err := app.GetReportPerDay(r[0], r[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := app.GetReportIn(r[0], r[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(got, expected) {
+			for i := range expected.Daily {
+				if i < len(got.Daily) && !reflect.DeepEqual(got.Daily[i], expected.Daily[i]) {
+					t.Errorf("%s - %s, day %d: expected %+v, got %+v", r[0], r[1], i, expected.Daily[i], got.Daily[i])
+					break
+				}
+			}
+			t.Errorf("%s - %s: expected %+v, got %+v", r[0], r[1], expected.Accumulated, got.Accumulated)
+		}
+	}
+}
+
+func benchmarkReport(b *testing.B, getReport func(*tt.TT, time.Time, time.Time) (tt.Report, error)) {
+	app := newTestApp(b)
+	rnd := rand.New(rand.NewSource(1)) // nolint:gosec
+	start := time.Date(2014, 1, 6, 0, 0, 0, 0, time.Local)
+	end := start.AddDate(10, 0, 0)
+
+	insertSyntheticTasks(b, app, rnd, start, int(end.Sub(start).Hours()/24))
+	if _, err := app.AddRule(tt.RuleWeeklyHours, 35, time.Time{}); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := getReport(app, start, end); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReport(b *testing.B) {
+	benchmarkReport(b, (*tt.TT).GetReportIn)
+}
+
+func BenchmarkReportPerDay(b *testing.B) {
+	benchmarkReport(b, (*tt.TT).GetReportPerDay)
+}
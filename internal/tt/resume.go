@@ -0,0 +1,140 @@
+package tt
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// recentTasksLimit is the number of distinct recent tasks that can be resumed.
+const recentTasksLimit = 10
+
+// GetRecentTasks returns the most recent stopped tasks, one per distinct
+// description and tags, most recent first and excluding the running task.
+// These are the candidates for Resume.
+func (tt *TT) GetRecentTasks() ([]Task, error) {
+	var candidates []Task
+
+	if err := tt.readTransaction(func(tx *sql.Tx) (err error) {
+		candidates, err = getResumeCandidates(tx)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return candidates, nil
+}
+
+// Resume starts again one of the tasks returned by GetRecentTasks at the given
+// time, selected either by its 1-based index, by a case-insensitive fuzzy
+// match on its description and tags, or the first one if selector is empty.
+// It returns the current task (if any) and the resumed one.
+func (tt *TT) Resume(selector string, at time.Time) (*Task, *Task, error) {
+	var current, next *Task
+
+	err := tt.transaction(func(tx *sql.Tx) (err error) {
+		candidates, err := getResumeCandidates(tx)
+		if err != nil {
+			return err
+		}
+
+		task, err := selectResumeCandidate(candidates, selector)
+		if err != nil {
+			return err
+		}
+
+		current, err = getCurrentTask(tx)
+		if err != nil && !errors.Is(err, ErrNoCurrentTask) {
+			return err
+		}
+
+		next, err = startTask(tx, current, task.Description, task.Tags, at, time.Time{})
+		return err
+	})
+
+	return current, next, err
+}
+
+func getResumeCandidates(tx *sql.Tx) ([]Task, error) {
+	tasks, err := getRecentTasks(tx, recentTasksLimit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := getCurrentTask(tx)
+	if err != nil && !errors.Is(err, ErrNoCurrentTask) {
+		return nil, err
+	}
+
+	ret := make([]Task, 0, len(tasks))
+	for _, v := range tasks {
+		if current != nil && current.Description == v.Description && reflect.DeepEqual(current.Tags, v.Tags) {
+			continue
+		}
+		ret = append(ret, v)
+	}
+
+	if len(ret) > recentTasksLimit {
+		ret = ret[:recentTasksLimit]
+	}
+
+	return ret, nil
+}
+
+func selectResumeCandidate(candidates []Task, selector string) (Task, error) {
+	if len(candidates) == 0 {
+		return Task{}, ErrNoTasks
+	}
+
+	selector = strings.TrimSpace(selector)
+	if selector == "" {
+		return candidates[0], nil
+	}
+
+	if i, err := strconv.Atoi(selector); err == nil {
+		if i < 1 || i > len(candidates) {
+			return Task{}, InvalidInputError(fmt.Sprintf("no recent task #%d, expected 1 to %d", i, len(candidates)))
+		}
+
+		return candidates[i-1], nil
+	}
+
+	// Prefer substring matches over scattered ones, then the most recent.
+	query := strings.ToLower(selector)
+	var fuzzy *Task
+	for i := range candidates {
+		str := strings.ToLower(candidates[i].Description + " " + strings.Join(candidates[i].Tags, " "))
+		if strings.Contains(str, query) {
+			return candidates[i], nil
+		}
+		if fuzzy == nil && isSubsequence(query, str) {
+			fuzzy = &candidates[i]
+		}
+	}
+
+	if fuzzy == nil {
+		return Task{}, InvalidInputError(fmt.Sprintf("no recent task matches \"%s\"", selector))
+	}
+
+	return *fuzzy, nil
+}
+
+// isSubsequence returns true if all the runes of needle appear in haystack in
+// the same order.
+func isSubsequence(needle, haystack string) bool {
+	runes := []rune(needle)
+	for _, r := range haystack {
+		if len(runes) == 0 {
+			break
+		}
+		if r == runes[0] {
+			runes = runes[1:]
+		}
+	}
+
+	return len(runes) == 0
+}
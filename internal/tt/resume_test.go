@@ -0,0 +1,64 @@
+// nolint:thelper
+package tt_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"tt/internal/tt"
+)
+
+func TestResume(t *testing.T) {
+	app := newTestApp(t)
+	start := time.Now().Add(-10 * time.Hour).Truncate(time.Second)
+
+	for i, raw := range []string{"review @acme", "docs", "review @acme", "review @other", "lunch @off"} {
+		if _, err := app.AddTask(raw, start.Add(time.Duration(i)*time.Hour), start.Add(time.Duration(i+1)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	recent, err := app.GetRecentTasks()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var actual []string
+	for _, v := range recent {
+		actual = append(actual, v.Description+" "+strings.Join(v.Tags, " "))
+	}
+	expected := []string{"lunch @off", "review @other", "review @acme", "docs "}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected recent tasks %q, got %q", expected, actual)
+	}
+
+	cases := []struct {
+		selector, desc string
+		tags           []string
+	}{
+		{"", "lunch", []string{"@off"}},
+		{"", "review", []string{"@other"}}, // the running task is not a candidate
+		{"3", "docs", []string{}},          // no tags, does not reuse the current ones
+		{"acme", "review", []string{"@acme"}},
+		{"lch", "lunch", []string{"@off"}},
+	}
+
+	for i, c := range cases {
+		_, next, err := app.Resume(c.selector, start.Add(6*time.Hour+time.Duration(i)*time.Minute))
+		if err != nil {
+			t.Fatalf("%q: %s", c.selector, err)
+		}
+		if next.Description != c.desc || !reflect.DeepEqual(next.Tags, c.tags) {
+			t.Errorf("%q: expected %s %v, got %s %v", c.selector, c.desc, c.tags, next.Description, next.Tags)
+		}
+	}
+
+	for _, selector := range []string{"0", "12", "nothing like it"} {
+		var inputError tt.InvalidInputError
+		if _, _, err := app.Resume(selector, time.Now()); !errors.As(err, &inputError) {
+			t.Errorf("%q: expected InvalidInputError, got %v", selector, err)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package tt
+
+import (
+	"tt/internal/util"
+)
+
+// ruleProxy is the RuleEntry as stored in DB.
+type ruleProxy struct {
+	ID         int64
+	Rule       string
+	Value      float64
+	ValidFrom  util.NullTimeAsTimestamp
+	ValidUntil util.NullTimeAsTimestamp
+}
+
+func ruleProxyFields() string {
+	// Order must match fields in ruleProxy.Scan
+	return `"ID", "Rule", "Value", "ValidFrom", "ValidUntil"`
+}
+
+func (r *ruleProxy) scan(s scannable) error {
+	// nolint:wrapcheck // proxy func
+	return s.Scan(
+		&r.ID,
+		&r.Rule,
+		&r.Value,
+		&r.ValidFrom,
+		&r.ValidUntil,
+	)
+}
+
+func newProxyFromRuleEntry(e RuleEntry) ruleProxy {
+	return ruleProxy{
+		ID:         e.ID,
+		Rule:       string(e.Rule),
+		Value:      e.Value,
+		ValidFrom:  util.NewNullTimeAsTimestamp(e.Start),
+		ValidUntil: util.NewNullTimeAsTimestamp(e.End),
+	}
+}
+
+func (r ruleProxy) RuleEntry() RuleEntry {
+	return RuleEntry{
+		ID:    r.ID,
+		Rule:  Rule(r.Rule),
+		Value: r.Value,
+		Start: r.ValidFrom.Time.Time(),
+		End:   r.ValidUntil.Time.Time(),
+	}
+}
@@ -1,60 +1,377 @@
 package tt
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 	"tt/internal/util"
 )
 
-type rule int
+type Rule string
 
 const (
-	ruleWeeklyHours   = iota
-	ruleHolidayFactor // worked holiday = (factor * worktime) of in lieu
-	ruleOnCallFactor  // on call worktime = (factor * worktime) of in lieu
+	RuleWeeklyHours   Rule = "WeeklyHours"   // spread Monday to Friday unless a schedule is set
+	RuleHolidayFactor Rule = "HolidayFactor" // worked holiday = (factor * worktime) of in lieu
+	RuleOnCallFactor  Rule = "OnCallFactor"  // on call worktime = (factor * worktime) of in lieu
+
+	// Index of the OvertimeStrategy to use, see overtimeStrategies.
+	RuleOvertimeStrategy Rule = "OvertimeStrategy"
+
+	// How to count workdays without anything recorded, see missingDayPolicyNames.
+	RuleMissingDayPolicy Rule = "MissingDayPolicy"
+
+	// How to resolve overlaps when adding or editing tasks, see OverlapPolicy.
+	RuleOverlapPolicy Rule = "OverlapPolicy"
+
+	// Running time after which a task is considered forgotten (0 disables the
+	// detection), and hour of the day at which forgotten tasks are stopped.
+	RuleMaxTaskHours Rule = "MaxTaskHours"
+	RuleEndOfDayHour Rule = "EndOfDayHour"
+
+	// Per-weekday schedule, see scheduleRules.
+	RuleMondayHours    Rule = "MondayHours"
+	RuleTuesdayHours   Rule = "TuesdayHours"
+	RuleWednesdayHours Rule = "WednesdayHours"
+	RuleThursdayHours  Rule = "ThursdayHours"
+	RuleFridayHours    Rule = "FridayHours"
+	RuleSaturdayHours  Rule = "SaturdayHours"
+	RuleSundayHours    Rule = "SundayHours"
 )
 
-type ruleEntry struct {
-	start time.Time  // can be a zero value to indicate the first applicable rule
-	end   *time.Time // can be nil for an ongoing rule
-	rule  rule
-	value float64
+// Rules lists all known rules.
+var Rules = []Rule{
+	RuleWeeklyHours,
+	RuleHolidayFactor,
+	RuleOnCallFactor,
+	RuleOvertimeStrategy,
+	RuleMissingDayPolicy,
+	RuleMaxTaskHours,
+	RuleEndOfDayHour,
+	RuleOverlapPolicy,
+	RuleMondayHours,
+	RuleTuesdayHours,
+	RuleWednesdayHours,
+	RuleThursdayHours,
+	RuleFridayHours,
+	RuleSaturdayHours,
+	RuleSundayHours,
+}
+
+// ParseRule returns the Rule matching the given name, case-insensitive.
+func ParseRule(name string) (Rule, error) {
+	for _, v := range Rules {
+		if strings.EqualFold(string(v), name) {
+			return v, nil
+		}
+	}
+
+	return "", InvalidInputError(fmt.Sprintf("unknown rule: %s", name))
+}
+
+// RuleValueNames returns the names of the values of an enumerated rule,
+// indexed by value, or nil if the rule takes a number.
+func RuleValueNames(rule Rule) []string {
+	switch rule { // nolint:exhaustive
+	case RuleOvertimeStrategy:
+		return overtimeStrategyNames()
+	case RuleMissingDayPolicy:
+		return missingDayPolicyNames()
+	case RuleOverlapPolicy:
+		return overlapPolicyNames()
+	default:
+		return nil
+	}
+}
+
+// ParseRuleValue parses a rule value, either a number or the name of one of
+// the values of an enumerated rule.
+func ParseRuleValue(rule Rule, str string) (float64, error) {
+	names := RuleValueNames(rule)
+	for i, v := range names {
+		if strings.EqualFold(v, str) {
+			return float64(i), nil
+		}
+	}
+
+	value, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		if names != nil {
+			return 0, InvalidInputError(fmt.Sprintf(
+				"invalid value for rule %s: %s, expected one of: %s",
+				rule, str, strings.Join(names, ", "),
+			))
+		}
+
+		return 0, InvalidInputError(fmt.Sprintf("invalid value for rule %s: %s", rule, str))
+	}
+
+	return value, nil
+}
+
+// FormatRuleValue returns the human-readable value of a rule.
+func FormatRuleValue(rule Rule, value float64) string {
+	names := RuleValueNames(rule)
+	if i := int(value); names != nil && float64(i) == value && i >= 0 && i < len(names) {
+		return names[i]
+	}
+
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+type RuleEntry struct {
+	ID    int64
+	Rule  Rule
+	Value float64
+	Start time.Time // can be a zero value to indicate the first applicable rule
+	End   time.Time // can be a zero value for an ongoing rule
 }
 
 // Sorted by start time.
-type rulesTimeline []ruleEntry
+type rulesTimeline []RuleEntry
 
 // Applicable rules at a specific time.
-type rulesSnapshot map[rule]float64
+type rulesSnapshot map[Rule]float64
 
-// forDay returns the applicable rules for a given day.
-// TODO: this is called for every day, should be memoized away.
+// forDay returns the applicable rules for a given day, use a rulesCursor to
+// go through many days.
 func (timeline rulesTimeline) forDay(t time.Time) rulesSnapshot {
 	day := util.GetStartOfDay(t)
 
-	ret := make(map[rule]float64, 3)
+	ret := make(map[Rule]float64, len(Rules))
 	for _, v := range timeline {
-		if v.start.After(day) {
+		if v.Start.After(day) {
 			// timeline is ordered, nothing for us past this point
 			break
 		}
 
-		if v.end == nil || day.Before(*v.end) {
-			ret[v.rule] = v.value
+		if v.End.IsZero() || day.Before(v.End) {
+			ret[v.Rule] = v.Value
+		}
+	}
+
+	return ret
+}
+
+// nextChange returns the first time after day at which an entry starts or
+// ends, or a zero time if there is none.
+func (timeline rulesTimeline) nextChange(day time.Time) time.Time {
+	var ret time.Time
+
+	for _, v := range timeline {
+		for _, t := range []time.Time{v.Start, v.End} {
+			if t.After(day) && (ret.IsZero() || t.Before(ret)) {
+				ret = t
+			}
 		}
 	}
 
 	return ret
 }
 
-// TODO in DB, configurable at runtime.
-func staticRulesTimeline() rulesTimeline {
-	end39 := time.Date(2022, 11, 14, 0, 0, 0, 0, time.Now().Location())
+// rulesCursor memoizes forDay over consecutive days: the rules only change
+// when an entry starts or ends, the snapshot is shared in between.
+type rulesCursor struct {
+	timeline    rulesTimeline
+	snapshot    rulesSnapshot
+	from, until time.Time // validity of snapshot, a zero until means forever
+}
 
-	return rulesTimeline{
-		ruleEntry{rule: ruleHolidayFactor, value: 2},
-		ruleEntry{rule: ruleOnCallFactor, value: 1.5},
-		ruleEntry{end: &end39, rule: ruleWeeklyHours, value: 39},
+func (timeline rulesTimeline) cursor() *rulesCursor {
+	return &rulesCursor{timeline: timeline}
+}
 
-		ruleEntry{start: end39, rule: ruleWeeklyHours, value: 35},
+func (c *rulesCursor) forDay(t time.Time) rulesSnapshot {
+	day := util.GetStartOfDay(t)
+
+	if c.snapshot == nil || day.Before(c.from) || (!c.until.IsZero() && !day.Before(c.until)) {
+		c.snapshot = c.timeline.forDay(day)
+		c.from, c.until = day, c.timeline.nextChange(day)
 	}
+
+	return c.snapshot
+}
+
+func getRulesTimeline(tx *sql.Tx) (rulesTimeline, error) {
+	query := fmt.Sprintf(
+		`SELECT %s FROM Rule ORDER BY ValidFrom ASC, ID ASC`,
+		ruleProxyFields(),
+	)
+
+	rows, err := tx.Query(query)
+	if err != nil {
+		return nil, BadQueryError{err, query, nil}
+	}
+	defer rows.Close()
+
+	var ret rulesTimeline
+	for rows.Next() {
+		var proxy ruleProxy
+		if err := proxy.scan(rows); err != nil {
+			return nil, BadQueryError{err, query, nil}
+		}
+
+		ret = append(ret, proxy.RuleEntry())
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, BadQueryError{err, query, nil}
+	}
+
+	return ret, nil
+}
+
+func getRuleEntry(tx *sql.Tx, id int64) (RuleEntry, error) {
+	var proxy ruleProxy
+
+	query := fmt.Sprintf(`SELECT %s FROM Rule WHERE ID = ?`, ruleProxyFields())
+	if err := proxy.scan(tx.QueryRow(query, id)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return RuleEntry{}, ErrInvalidRuleID
+		}
+
+		return RuleEntry{}, BadQueryError{err, query, []interface{}{id}}
+	}
+
+	return proxy.RuleEntry(), nil
+}
+
+func (e *RuleEntry) insert(tx *sql.Tx) error {
+	proxy := newProxyFromRuleEntry(*e)
+
+	var err error
+	e.ID, err = execWithLastID(
+		tx,
+		`INSERT INTO Rule (Rule, Value, ValidFrom, ValidUntil) VALUES (?, ?, ?, ?)`,
+		proxy.Rule,
+		proxy.Value,
+		proxy.ValidFrom,
+		proxy.ValidUntil,
+	)
+
+	return err
+}
+
+// GetRules returns the whole rules timeline.
+func (tt *TT) GetRules() ([]RuleEntry, error) {
+	var timeline rulesTimeline
+
+	if err := tt.readTransaction(func(tx *sql.Tx) (err error) {
+		timeline, err = getRulesTimeline(tx)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return timeline, nil
+}
+
+// AddRule sets a new value for a rule starting on the given day. A zero day
+// makes the rule applicable since forever, or from today if the rule already
+// has entries, which would otherwise take precedence.
+// The currently ongoing entries for the same rule are ended on that day.
+func (tt *TT) AddRule(rule Rule, value float64, from time.Time) (RuleEntry, error) {
+	entry, err := newRuleEntry(rule, value, from)
+	if err != nil {
+		return RuleEntry{}, err
+	}
+
+	err = tt.transaction(func(tx *sql.Tx) error {
+		return addRuleEntry(tx, &entry)
+	})
+
+	return entry, err
+}
+
+func newRuleEntry(rule Rule, value float64, from time.Time) (RuleEntry, error) {
+	if _, err := ParseRule(string(rule)); err != nil {
+		return RuleEntry{}, err
+	}
+
+	if value < 0 {
+		return RuleEntry{}, InvalidInputError(fmt.Sprintf("invalid negative value for rule %s: %v", rule, value))
+	}
+
+	if rule == RuleEndOfDayHour && value >= 24 {
+		return RuleEntry{}, InvalidInputError(fmt.Sprintf("invalid hour for rule %s: %v", rule, value))
+	}
+
+	if names := RuleValueNames(rule); names != nil {
+		if i := int(value); float64(i) != value || i >= len(names) {
+			return RuleEntry{}, InvalidInputError(fmt.Sprintf("invalid value for rule %s: %v", rule, value))
+		}
+	}
+
+	entry := RuleEntry{Rule: rule, Value: value}
+	if !from.IsZero() {
+		entry.Start = util.GetStartOfDay(from)
+	}
+
+	return entry, nil
+}
+
+// addRuleEntry ends the ongoing entries for the same rule and inserts the new
+// entry. An undated entry starts today if the rule already has entries.
+func addRuleEntry(tx *sql.Tx, entry *RuleEntry) error {
+	if entry.Start.IsZero() {
+		var exists bool
+		query := `SELECT EXISTS (SELECT 1 FROM Rule WHERE Rule = ?)`
+		if err := tx.QueryRow(query, entry.Rule).Scan(&exists); err != nil {
+			return BadQueryError{err, query, []interface{}{entry.Rule}}
+		}
+
+		if exists {
+			entry.Start = util.GetStartOfDay(time.Now())
+		}
+	}
+
+	if !entry.Start.IsZero() {
+		start := util.NewNullTimeAsTimestamp(entry.Start)
+		if err := exec(
+			tx,
+			`UPDATE Rule SET ValidUntil = ?
+            WHERE Rule = ?
+              AND (ValidFrom IS NULL OR ValidFrom < ?)
+              AND (ValidUntil IS NULL OR ValidUntil > ?)`,
+			start, entry.Rule, start, start,
+		); err != nil {
+			return err
+		}
+	}
+
+	return entry.insert(tx)
+}
+
+// EndRule stops applying a rule entry starting on the given day.
+func (tt *TT) EndRule(id int64, at time.Time) error {
+	end := util.GetStartOfDay(at)
+
+	return tt.transaction(func(tx *sql.Tx) error {
+		entry, err := getRuleEntry(tx, id)
+		if err != nil {
+			return err
+		}
+
+		if !entry.Start.Before(end) {
+			return InvalidInputError(fmt.Sprintf(
+				"rule #%d cannot end on %s, it starts on %s",
+				id, end.Format("2006-01-02"), entry.Start.Format("2006-01-02"),
+			))
+		}
+
+		return exec(tx, `UPDATE Rule SET ValidUntil = ? WHERE ID = ?`, util.NewNullTimeAsTimestamp(end), id)
+	})
+}
+
+// DeleteRule removes a rule entry from the timeline.
+func (tt *TT) DeleteRule(id int64) error {
+	return tt.transaction(func(tx *sql.Tx) error {
+		if _, err := getRuleEntry(tx, id); err != nil {
+			return err
+		}
+
+		return exec(tx, `DELETE FROM Rule WHERE ID = ?`, id)
+	})
 }
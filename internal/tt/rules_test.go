@@ -0,0 +1,137 @@
+// nolint:thelper
+package tt_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"tt/internal/tt"
+)
+
+func TestRules(t *testing.T) {
+	app := newTestApp(t)
+
+	if _, _, err := app.GetDurationLeft(); !errors.Is(err, tt.ErrNotConfigured) {
+		t.Errorf("expected ErrNotConfigured, got %v", err)
+	}
+
+	first, err := app.AddRule(tt.RuleWeeklyHours, 39, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	switchDay := time.Date(2022, 11, 14, 13, 37, 0, 0, time.Local)
+	second, err := app.AddRule(tt.RuleWeeklyHours, 35, switchDay)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := app.GetRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Default factors and MaxTaskHours + our two entries.
+	if len(rules) != 5 {
+		t.Fatalf("expected 5 rules, got %d", len(rules))
+	}
+
+	for _, v := range rules {
+		switch v.ID {
+		case first.ID:
+			if !v.End.Equal(time.Date(2022, 11, 14, 0, 0, 0, 0, time.Local)) {
+				t.Errorf("expected first rule to end on the second rule start, got %s", v.End)
+			}
+		case second.ID:
+			if !v.End.IsZero() {
+				t.Errorf("expected second rule to be ongoing, got %s", v.End)
+			}
+		}
+	}
+
+	if _, _, err := app.GetDurationLeft(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	if err := app.EndRule(second.ID, switchDay); err == nil {
+		t.Error("expected an error when ending a rule on its start day")
+	}
+
+	if err := app.EndRule(second.ID, switchDay.AddDate(0, 0, 1)); err != nil {
+		t.Error(err)
+	}
+
+	if err := app.DeleteRule(second.ID); err != nil {
+		t.Error(err)
+	}
+
+	if err := app.DeleteRule(second.ID); !errors.Is(err, tt.ErrInvalidRuleID) {
+		t.Errorf("expected ErrInvalidRuleID, got %v", err)
+	}
+
+	if _, err := tt.ParseRule("weeklyhours"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUndatedRuleAppliesToday(t *testing.T) {
+	app := newTestApp(t)
+
+	if _, err := app.AddRule(tt.RuleWeeklyHours, 35, time.Date(2022, 11, 14, 0, 0, 0, 0, time.Local)); err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := app.AddRule(tt.RuleWeeklyHours, 40, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if today := time.Now(); entry.Start.Year() != today.Year() || entry.Start.YearDay() != today.YearDay() {
+		t.Errorf("expected the undated rule to start today, got %s", entry.Start)
+	}
+
+	schedule, err := app.GetSchedule(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schedule.Weekday(time.Monday) != 8*time.Hour {
+		t.Errorf("expected the new weekly hours to apply today, got %v", schedule)
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	app := newTestApp(t)
+
+	if _, err := app.AddRule(tt.RuleWeeklyHours, 35, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	schedule, err := app.GetSchedule(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Weekly hours are spread from Monday to Friday.
+	if expected := (tt.Schedule{7 * time.Hour, 7 * time.Hour, 7 * time.Hour, 7 * time.Hour, 7 * time.Hour}); schedule != expected {
+		t.Errorf("expected %v, got %v", expected, schedule)
+	}
+
+	if err := app.SetSchedule([7]float64{6, 6, 0, 6, 4}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	schedule, err = app.GetSchedule(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if schedule.Weekday(time.Wednesday) != 0 || schedule.Weekday(time.Friday) != 4*time.Hour {
+		t.Errorf("unexpected schedule: %v", schedule)
+	}
+	if schedule.Total() != 22*time.Hour {
+		t.Errorf("expected 22h total, got %s", schedule.Total())
+	}
+
+	if err := app.SetSchedule([7]float64{}, time.Now()); err == nil {
+		t.Error("expected an error for an empty schedule")
+	}
+}
@@ -0,0 +1,138 @@
+package tt
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+	"tt/internal/util"
+)
+
+// scheduleRules holds the per-weekday target rules, indexed by
+// util.WeekdayOffset.
+var scheduleRules = [7]Rule{
+	RuleMondayHours,
+	RuleTuesdayHours,
+	RuleWednesdayHours,
+	RuleThursdayHours,
+	RuleFridayHours,
+	RuleSaturdayHours,
+	RuleSundayHours,
+}
+
+// Schedule holds the expected work time of each weekday, starting on Monday.
+type Schedule [7]time.Duration
+
+func (s Schedule) Weekday(wd time.Weekday) time.Duration {
+	return s[util.WeekdayOffset(wd)]
+}
+
+func (s Schedule) Total() time.Duration {
+	var acc time.Duration
+	for _, v := range s {
+		acc += v
+	}
+
+	return acc
+}
+
+// hasSchedule returns true if at least one per-weekday rule applies.
+func (rules rulesSnapshot) hasSchedule() bool {
+	for _, v := range scheduleRules {
+		if _, ok := rules[v]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// schedule returns the per-weekday schedule if any, or the weekly hours
+// spread from Monday to Friday.
+func (rules rulesSnapshot) schedule() Schedule {
+	var ret Schedule
+
+	if rules.hasSchedule() {
+		for i, v := range scheduleRules {
+			ret[i] = hoursToDuration(rules[v])
+		}
+
+		return ret
+	}
+
+	daily := hoursToDuration(rules[RuleWeeklyHours]) / 5
+	for i := 0; i < 5; i++ {
+		ret[i] = daily
+	}
+
+	return ret
+}
+
+// isOffDay returns true for days without work expected. Without any schedule
+// nor weekly hours configured, weekends are still considered off.
+func (rules rulesSnapshot) isOffDay(wd time.Weekday) bool {
+	schedule := rules.schedule()
+	if schedule.Total() <= 0 {
+		return wd == time.Saturday || wd == time.Sunday
+	}
+
+	return schedule.Weekday(wd) <= 0
+}
+
+func hoursToDuration(hours float64) time.Duration {
+	return time.Duration(float64(time.Hour) * hours)
+}
+
+// GetSchedule returns the schedule applicable on the given day.
+func (tt *TT) GetSchedule(day time.Time) (Schedule, error) {
+	var ret Schedule
+
+	if err := tt.readTransaction(func(tx *sql.Tx) error {
+		timeline, err := getRulesTimeline(tx)
+		if err != nil {
+			return err
+		}
+
+		ret = timeline.forDay(day).schedule()
+		return nil
+	}); err != nil {
+		return Schedule{}, err
+	}
+
+	return ret, nil
+}
+
+// SetSchedule adds per-weekday rules for all weekdays starting on the given
+// day, hours are indexed from Monday.
+func (tt *TT) SetSchedule(hours [7]float64, from time.Time) error {
+	entries := make([]RuleEntry, 0, len(hours))
+	for i, v := range hours {
+		if v > 24 {
+			return InvalidInputError(fmt.Sprintf("invalid schedule, more than 24 hours on %s: %v", scheduleRules[i], v))
+		}
+
+		entry, err := newRuleEntry(scheduleRules[i], v, from)
+		if err != nil {
+			return err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	var total float64
+	for _, v := range hours {
+		total += v
+	}
+	if total <= 0 {
+		return InvalidInputError(fmt.Sprintf("invalid schedule, no work expected: %v", hours))
+	}
+
+	return tt.transaction(func(tx *sql.Tx) error {
+		for i := range entries {
+			if err := addRuleEntry(tx, &entries[i]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,124 @@
+package tt
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// SplitTask cuts the task in two at the given time, the second part gets the
+// given description and tags, or keeps the original ones if desc is empty.
+// It returns both parts, the second one is running if the task was.
+func (tt *TT) SplitTask(id int64, at time.Time, desc string, tags []string) (*Task, *Task, error) {
+	var first, second *Task
+
+	err := tt.transaction(func(tx *sql.Tx) (err error) {
+		first, err = getTask(tx, id)
+		if err != nil {
+			return err
+		}
+
+		if !at.After(first.StartedAt) || !at.Before(first.end()) {
+			return InvalidInputError(fmt.Sprintf(
+				"cannot split \"%s\" at %s, it is not within the task",
+				first.Description,
+				at.Format(timeFormat),
+			))
+		}
+
+		if desc == "" {
+			desc, tags = first.Description, first.Tags
+		}
+
+		second = &Task{
+			Description: desc,
+			Tags:        tags,
+			StartedAt:   at,
+			StoppedAt:   first.StoppedAt,
+			Deadline:    first.Deadline,
+		}
+		first.StoppedAt, first.Deadline = at, time.Time{}
+
+		if err := first.save(tx); err != nil {
+			return err
+		}
+
+		return second.insert(tx)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return first, second, nil
+}
+
+// MergeTasks joins contiguous tasks into the first one, which keeps its
+// description and gets the tags of all of them.
+func (tt *TT) MergeTasks(ids []int64) (*Task, error) {
+	if len(ids) < 2 {
+		return nil, InvalidInputError("at least two tasks are needed to merge")
+	}
+
+	var merged *Task
+
+	err := tt.transaction(func(tx *sql.Tx) error {
+		tasks := make([]*Task, 0, len(ids))
+		for _, id := range ids {
+			task, err := getTask(tx, id)
+			if err != nil {
+				return err
+			}
+			tasks = append(tasks, task)
+		}
+
+		sort.Slice(tasks, func(i, j int) bool { return tasks[i].StartedAt.Before(tasks[j].StartedAt) })
+
+		merged = tasks[0]
+		for _, v := range tasks[1:] {
+			if v.ID == merged.ID {
+				return InvalidInputError("a task cannot be merged with itself")
+			}
+
+			if !v.StartedAt.Equal(merged.StoppedAt) {
+				return InvalidInputError(fmt.Sprintf(
+					"cannot merge \"%s\" with \"%s\" starting at %s, the tasks must follow each other",
+					merged.Description,
+					v.Description,
+					v.StartedAt.Format(timeFormat),
+				))
+			}
+
+			if err := exec(tx, `UPDATE TaskStack SET TaskID = ? WHERE TaskID = ?`, merged.ID, v.ID); err != nil {
+				return err
+			}
+
+			if err := deleteTask(tx, v.ID); err != nil {
+				return err
+			}
+
+			merged.StoppedAt, merged.Deadline = v.StoppedAt, v.Deadline
+			merged.Tags = mergeTags(merged.Tags, v.Tags)
+		}
+
+		return merged.save(tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return merged, nil
+}
+
+// mergeTags returns the tags of a followed by the ones of b it did not have.
+func mergeTags(a, b []string) []string {
+	ret := append([]string{}, a...)
+
+	for _, v := range b {
+		if !contains(ret, v) {
+			ret = append(ret, v)
+		}
+	}
+
+	return ret
+}
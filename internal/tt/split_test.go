@@ -0,0 +1,81 @@
+// nolint:thelper
+package tt_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+	"tt/internal/tt"
+)
+
+func TestSplitMergeTasks(t *testing.T) {
+	app := newTestApp(t)
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.Local)
+
+	task, err := app.AddTask("work @acme", day.Add(9*time.Hour), day.Add(12*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := app.SplitTask(task.ID, day.Add(12*time.Hour), "", nil); !isInvalidInput(err) {
+		t.Errorf("expected an invalid input error, got %v", err)
+	}
+
+	first, second, err := app.SplitTask(task.ID, day.Add(10*time.Hour), "review", []string{"@bob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !first.StoppedAt.Equal(day.Add(10*time.Hour)) || !second.StartedAt.Equal(first.StoppedAt) ||
+		!second.StoppedAt.Equal(day.Add(12*time.Hour)) || second.Description != "review" {
+		t.Fatalf("unexpected split: %+v, %+v", first, second)
+	}
+
+	_, third, err := app.SplitTask(second.ID, day.Add(11*time.Hour), "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third.Description != "review" || !reflect.DeepEqual(third.Tags, []string{"@bob"}) {
+		t.Errorf("expected the split to keep the description and tags, got %+v", third)
+	}
+
+	if _, err := app.MergeTasks([]int64{first.ID, third.ID}); !isInvalidInput(err) {
+		t.Errorf("expected non-contiguous tasks to be refused, got %v", err)
+	}
+
+	merged, err := app.MergeTasks([]int64{third.ID, first.ID, second.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged.ID != first.ID || merged.Description != "work" || !merged.StoppedAt.Equal(day.Add(12*time.Hour)) ||
+		!reflect.DeepEqual(merged.Tags, []string{"@acme", "@bob"}) {
+		t.Errorf("unexpected merge: %+v", merged)
+	}
+
+	if _, err := app.GetTask(second.ID); !errors.Is(err, tt.ErrInvalidTaskID) {
+		t.Errorf("expected the merged tasks to be deleted, got %v", err)
+	}
+}
+
+func TestSplitRunningTask(t *testing.T) {
+	app := newTestApp(t)
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	_, current, err := app.StartAt("work", start)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, second, err := app.SplitTask(current.ID, start.Add(30*time.Minute), "meeting", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	running, err := app.CurrentTask()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if running.ID != second.ID || running.Description != "meeting" {
+		t.Errorf("expected the second part to be running, got %+v", running)
+	}
+}
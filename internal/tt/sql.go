@@ -3,6 +3,7 @@ package tt
 import (
 	"database/sql"
 	"database/sql/driver"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
@@ -12,14 +13,23 @@ type transactionCallback func(*sql.Tx) error
 
 // transaction runs the given callback in a SQL transaction and either COMMIT
 // if the returned error is nil, or ROLLBACK if the error is non-nil.
-// All interactions with the DB should go through this function.
+// All changes to the DB should go through this function, reads through
+// readTransaction.
 func (tt *TT) transaction(cb transactionCallback) error {
-	tx, err := tt.db.Begin()
+	tx, err := tt.writeDB.Begin()
 	if err != nil {
 		return DatabaseError(err.Error())
 	}
 
-	if err := cb(tx); err != nil {
+	err = setHistorySource(tx, tt.source)
+	if err == nil {
+		err = cb(tx)
+	}
+	if err == nil && tt.migrated {
+		err = recordJournal(tx)
+	}
+
+	if err != nil {
 		if err2 := tx.Rollback(); err2 != nil {
 			return DatabaseError(fmt.Sprintf("rollback error: %s\noriginal error: %s", err2, err))
 		}
@@ -34,6 +44,46 @@ func (tt *TT) transaction(cb transactionCallback) error {
 	return nil
 }
 
+// readTransaction runs the given callback in a deferred SQL transaction, which
+// does not wait for other processes to finish their changes. It always does a
+// ROLLBACK: anything changing the DB must go through transaction.
+func (tt *TT) readTransaction(cb transactionCallback) error {
+	tx, err := tt.db.Begin()
+	if err != nil {
+		return DatabaseError(err.Error())
+	}
+
+	err = cb(tx)
+	if err2 := tx.Rollback(); err2 != nil && err == nil {
+		err = DatabaseError(err2.Error())
+	}
+
+	return err
+}
+
+var errDryRun = errors.New("dry run")
+
+// transactionOrDryRun is transaction, but it always does a ROLLBACK if dryRun
+// is true.
+func (tt *TT) transactionOrDryRun(dryRun bool, cb transactionCallback) error {
+	if !dryRun {
+		return tt.transaction(cb)
+	}
+
+	err := tt.transaction(func(tx *sql.Tx) error {
+		if err := cb(tx); err != nil {
+			return err
+		}
+
+		return errDryRun
+	})
+	if errors.Is(err, errDryRun) {
+		return nil
+	}
+
+	return err
+}
+
 func exec(tx *sql.Tx, query string, params ...interface{}) error {
 	if _, err := tx.Exec(query, params...); err != nil {
 		return BadQueryError{err, query, params}
@@ -52,6 +102,16 @@ func execWithLastID(tx *sql.Tx, query string, params ...interface{}) (int64, err
 	return res.LastInsertId()
 }
 
+func execWithRowsAffected(tx *sql.Tx, query string, params ...interface{}) (int64, error) {
+	res, err := tx.Exec(query, params...)
+	if err != nil {
+		return -1, BadQueryError{err, query, params}
+	}
+
+	// nolint: wrapcheck // nah, proxy func.
+	return res.RowsAffected()
+}
+
 func debugPrintQuery(query string, params ...interface{}) { // nolint:deadcode,unused
 	for _, v := range params {
 		var str string
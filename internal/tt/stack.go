@@ -0,0 +1,104 @@
+package tt
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Push suspends the current task on the stack and starts the interrupting
+// task described by raw at the given time.
+// It returns the suspended task and the interrupting one.
+func (tt *TT) Push(raw string, at time.Time) (*Task, *Task, error) {
+	desc, tags := ParseRawDesc(raw)
+	var current, next *Task
+
+	err := tt.transaction(func(tx *sql.Tx) (err error) {
+		current, err = getCurrentTask(tx)
+		if err != nil {
+			return err
+		}
+
+		if desc == "" || desc == current.Description {
+			return InvalidInputError(fmt.Sprintf("the interrupting task must differ from \"%s\"", current.Description))
+		}
+
+		if err := exec(tx, `INSERT INTO TaskStack (TaskID) VALUES (?)`, current.ID); err != nil {
+			return err
+		}
+
+		next, err = startTask(tx, current, desc, tags, at, time.Time{})
+		return err
+	})
+
+	return current, next, err
+}
+
+// Pop stops the current task (if any) at the given time and resumes the last
+// suspended task with its description and tags.
+// It returns the stopped task (if any) and the resumed one. ErrContinue is
+// returned if the suspended task was already running again, it is still
+// removed from the stack.
+func (tt *TT) Pop(at time.Time) (*Task, *Task, error) {
+	var (
+		current, next *Task
+		continued     bool
+	)
+
+	err := tt.transaction(func(tx *sql.Tx) (err error) {
+		var stackID, taskID int64
+		query := `SELECT ID, TaskID FROM TaskStack ORDER BY ID DESC LIMIT 1`
+		if err := tx.QueryRow(query).Scan(&stackID, &taskID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrEmptyStack
+			}
+			return BadQueryError{err, query, nil}
+		}
+
+		if err := exec(tx, `DELETE FROM TaskStack WHERE ID = ?`, stackID); err != nil {
+			return err
+		}
+
+		suspended, err := getTask(tx, taskID)
+		if err != nil {
+			return err
+		}
+
+		current, err = getCurrentTask(tx)
+		if err != nil && !errors.Is(err, ErrNoCurrentTask) {
+			return err
+		}
+
+		next, err = startTask(tx, current, suspended.Description, suspended.Tags, at, time.Time{})
+		if errors.Is(err, ErrContinue) {
+			// Keep the stack entry deleted.
+			continued, err = true, nil
+		}
+		return err
+	})
+	if err == nil && continued {
+		err = ErrContinue
+	}
+
+	return current, next, err
+}
+
+// GetStack returns the suspended tasks, the next one to be resumed first.
+func (tt *TT) GetStack() ([]Task, error) {
+	var tasks []Task
+
+	if err := tt.readTransaction(func(tx *sql.Tx) (err error) {
+		tasks, err = queryTasks(tx, fmt.Sprintf(
+			`SELECT %s FROM Task
+            JOIN TaskStack ON TaskStack.TaskID = Task.ID
+            ORDER BY TaskStack.ID DESC`,
+			taskProxyFields(),
+		))
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
@@ -0,0 +1,93 @@
+// nolint:thelper
+package tt_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+	"tt/internal/tt"
+)
+
+func TestStack(t *testing.T) {
+	app := newTestApp(t)
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	if _, _, err := app.Pop(start); !errors.Is(err, tt.ErrEmptyStack) {
+		t.Errorf("expected ErrEmptyStack, got %v", err)
+	}
+	if _, _, err := app.Push("interrupt", start); !errors.Is(err, tt.ErrNoCurrentTask) {
+		t.Errorf("expected ErrNoCurrentTask, got %v", err)
+	}
+
+	if _, _, err := app.StartAt("work @acme @dev", start); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := app.Push("help bob", start.Add(10*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := app.Push("coffee @off", start.Add(20*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	stack, err := app.GetStack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stack) != 2 || stack[0].Description != "help bob" || stack[1].Description != "work" {
+		t.Fatalf("unexpected stack: %+v", stack)
+	}
+
+	expected := []struct {
+		desc string
+		tags []string
+	}{
+		{"help bob", []string{}},
+		{"work", []string{"@acme", "@dev"}},
+	}
+	for i, e := range expected {
+		at := start.Add(time.Duration(30+10*i) * time.Minute)
+		stopped, resumed, err := app.Pop(at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !stopped.StoppedAt.Equal(at) || !resumed.StartedAt.Equal(at) {
+			t.Errorf("expected the tasks to switch at %s, got %s and %s", at, stopped.StoppedAt, resumed.StartedAt)
+		}
+		if resumed.Description != e.desc || !reflect.DeepEqual(resumed.Tags, e.tags) {
+			t.Errorf("expected to resume %s %v, got %s %v", e.desc, e.tags, resumed.Description, resumed.Tags)
+		}
+	}
+
+	if _, _, err := app.Pop(start.Add(time.Hour)); !errors.Is(err, tt.ErrEmptyStack) {
+		t.Errorf("expected ErrEmptyStack, got %v", err)
+	}
+}
+
+func TestPopResumedTask(t *testing.T) {
+	app := newTestApp(t)
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	if _, _, err := app.StartAt("work @acme", start); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := app.Push("help bob", start.Add(10*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Resumed by hand rather than with Pop.
+	if _, _, err := app.StartAt("work @acme", start.Add(20*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := app.Pop(start.Add(30 * time.Minute)); !errors.Is(err, tt.ErrContinue) {
+		t.Errorf("expected ErrContinue, got %v", err)
+	}
+
+	if stack, err := app.GetStack(); err != nil || len(stack) != 0 {
+		t.Errorf("expected an empty stack, got %+v, %v", stack, err)
+	}
+	if _, _, err := app.Pop(start.Add(40 * time.Minute)); !errors.Is(err, tt.ErrEmptyStack) {
+		t.Errorf("expected ErrEmptyStack, got %v", err)
+	}
+}
@@ -8,12 +8,16 @@ import (
 	"tt/internal/util"
 )
 
+// timeFormat is used to show task boundaries in error messages.
+const timeFormat = "2006-01-02 15:04"
+
 type Task struct {
 	ID          int64
 	Description string
 	Tags        []string
 	StartedAt   time.Time
 	StoppedAt   time.Time // will be a zero time for the task in progress
+	Deadline    time.Time // planned end, zero if the task is not timeboxed
 }
 
 func (t *Task) HasTag(tag string) bool {
@@ -38,49 +42,128 @@ func NewTask(desc string, tags []string) *Task {
 	}
 }
 
+// update saves the task, it must not overlap any other task.
 func (t *Task) update(tx *sql.Tx) error {
 	if t.ID == 0 {
 		return ErrInvalidTaskID
 	}
 
-	proxy, err := newProxyFromTask(*t)
-	if err != nil {
+	if err := t.checkOverlaps(tx); err != nil {
 		return err
 	}
 
-	return exec(
+	return t.save(tx)
+}
+
+// save is update without the overlap check.
+func (t *Task) save(tx *sql.Tx) error {
+	proxy := newProxyFromTask(*t)
+	if err := exec(
 		tx,
 		`UPDATE Task
         SET Description = ?,
             StartedAt = ?,
             StoppedAt = ?,
-            Tags = ?
+            Deadline = ?
         WHERE ID = ?`,
 		proxy.Description,
 		proxy.StartedAt,
 		proxy.StoppedAt,
-		proxy.Tags,
+		proxy.Deadline,
 		t.ID,
-	)
+	); err != nil {
+		return err
+	}
+
+	if err := setTaskTags(tx, t.ID, t.Tags); err != nil {
+		return err
+	}
+
+	return addTaskHistory(tx, t.ID, historyUpdate)
 }
 
+// insert creates the task, it must not overlap any other task.
 func (t *Task) insert(tx *sql.Tx) error {
-	proxy, err := newProxyFromTask(*t)
-	if err != nil {
+	if err := t.checkOverlaps(tx); err != nil {
 		return err
 	}
 
+	proxy := newProxyFromTask(*t)
+
+	var err error
 	t.ID, err = execWithLastID(
 		tx,
-		`INSERT INTO Task (Description, StartedAt, StoppedAt, Tags)
+		`INSERT INTO Task (Description, StartedAt, StoppedAt, Deadline)
         VALUES (?, ?, ?, ?)`,
 		proxy.Description,
 		proxy.StartedAt,
 		proxy.StoppedAt,
-		proxy.Tags,
+		proxy.Deadline,
 	)
+	if err != nil {
+		return err
+	}
+
+	if err := setTaskTags(tx, t.ID, t.Tags); err != nil {
+		return err
+	}
 
-	return err
+	return addTaskHistory(tx, t.ID, historyInsert)
+}
+
+// checkOverlaps returns an error if the task shares some time with another
+// task.
+func (t *Task) checkOverlaps(tx *sql.Tx) error {
+	overlapping, err := getOverlappingTasks(tx, t.StartedAt, t.end())
+	if err != nil {
+		return err
+	}
+
+	for _, v := range overlapping {
+		if v.ID != t.ID {
+			return newOverlapError(v)
+		}
+	}
+
+	return nil
+}
+
+// end returns when the task stopped, or now for the running task.
+func (t *Task) end() time.Time {
+	if t.StoppedAt.IsZero() {
+		return time.Now()
+	}
+
+	return t.StoppedAt
+}
+
+// setTaskTags replaces the tags of the given task, creating missing tags and
+// removing the ones that are not used anymore.
+func setTaskTags(tx *sql.Tx, id int64, tags []string) error {
+	if err := exec(tx, `DELETE FROM TaskTag WHERE TaskID = ?`, id); err != nil {
+		return err
+	}
+
+	for i, tag := range tags {
+		if err := exec(tx, `INSERT OR IGNORE INTO Tag (Name) VALUES (?)`, tag); err != nil {
+			return err
+		}
+
+		if err := exec(
+			tx,
+			`INSERT OR IGNORE INTO TaskTag (TaskID, TagID, Position)
+            SELECT ?, ID, ? FROM Tag WHERE Name = ?`,
+			id, i, tag,
+		); err != nil {
+			return err
+		}
+	}
+
+	return deleteUnusedTags(tx)
+}
+
+func deleteUnusedTags(tx *sql.Tx) error {
+	return exec(tx, `DELETE FROM Tag WHERE ID NOT IN (SELECT TagID FROM TaskTag)`)
 }
 
 func (t *Task) Duration() time.Duration {
@@ -101,7 +184,7 @@ func getAllTasks(tx *sql.Tx) ([]Task, error) {
 func (tt *TT) wrapTaskQuery(cb func(tx *sql.Tx) ([]Task, error)) ([]Task, error) {
 	var tasks []Task
 
-	if err := tt.transaction(func(tx *sql.Tx) (err error) {
+	if err := tt.readTransaction(func(tx *sql.Tx) (err error) {
 		tasks, err = cb(tx)
 		return err
 	}); err != nil {
@@ -122,19 +205,86 @@ func getFirstTask(tx *sql.Tx) ([]Task, error) {
 	))
 }
 
-func getTasksInRange(tx *sql.Tx, startTime, endTime time.Time) ([]Task, error) {
-	start, end := startTime.Unix(), endTime.Unix()
-
-	query := fmt.Sprintf(
+// tasksInRangeQuery selects the tasks sharing some time with a range given by
+// its end and start, including the ones spanning all of it and the running
+// one if it started before its end.
+func tasksInRangeQuery() string {
+	return fmt.Sprintf(
 		`SELECT %s FROM Task
-        WHERE (StartedAt >= ? AND StartedAt < ?)
-           OR (StoppedAt > ? AND StoppedAt < ?)
-           OR (StartedAt >= ? AND StartedAt < ? AND StoppedAt IS NULL)
+        WHERE StartedAt < ? AND (StoppedAt > ? OR StoppedAt IS NULL)
         ORDER BY StartedAt ASC`,
 		taskProxyFields(),
 	)
+}
+
+// getTasksInRange returns the tasks sharing some time with the given range.
+func getTasksInRange(tx *sql.Tx, start, end time.Time) ([]Task, error) {
+	return queryTasks(tx, tasksInRangeQuery(), end.Unix(), start.Unix())
+}
+
+// taskStream reads the tasks of a range one at a time, ordered by start, so
+// that long ranges don't have to be loaded at once.
+type taskStream struct {
+	rows  *sql.Rows
+	query string
+	next  *Task // read but not returned yet
+}
+
+// streamTasksInRange returns the tasks of getTasksInRange as a stream, it
+// must be closed after use.
+func streamTasksInRange(tx *sql.Tx, start, end time.Time) (*taskStream, error) {
+	query := tasksInRangeQuery()
+
+	rows, err := tx.Query(query, end.Unix(), start.Unix())
+	if err != nil {
+		return nil, BadQueryError{err, query, nil}
+	}
+
+	return &taskStream{rows: rows, query: query}, nil
+}
 
-	return queryTasks(tx, query, start, end, start, end, start, end)
+// startingBefore returns the next tasks starting before the given time.
+func (s *taskStream) startingBefore(t time.Time) ([]Task, error) {
+	var ret []Task
+
+	for {
+		if s.next == nil {
+			if !s.rows.Next() {
+				if err := s.rows.Err(); err != nil {
+					return nil, BadQueryError{err, s.query, nil}
+				}
+
+				return ret, nil
+			}
+
+			var proxy taskProxy
+			if err := proxy.scan(s.rows); err != nil {
+				return nil, BadQueryError{err, s.query, nil}
+			}
+
+			task, err := proxy.Task()
+			if err != nil {
+				return nil, err
+			}
+
+			s.next = task
+		}
+
+		if !s.next.StartedAt.Before(t) {
+			return ret, nil
+		}
+
+		ret = append(ret, *s.next)
+		s.next = nil
+	}
+}
+
+func (s *taskStream) Close() error {
+	if err := s.rows.Close(); err != nil {
+		return BadQueryError{err, s.query, nil}
+	}
+
+	return nil
 }
 
 func queryTasks(tx *sql.Tx, query string, params ...interface{}) ([]Task, error) {
@@ -170,6 +320,67 @@ func queryTasks(tx *sql.Tx, query string, params ...interface{}) ([]Task, error)
 	return ret, nil
 }
 
+func getTask(tx *sql.Tx, id int64) (*Task, error) {
+	tasks, err := queryTasks(tx, fmt.Sprintf(
+		`SELECT %s FROM Task WHERE ID = ?`,
+		taskProxyFields(),
+	), id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tasks) == 0 {
+		return nil, ErrInvalidTaskID
+	}
+
+	return &tasks[0], nil
+}
+
+// getRecentTasks returns the most recent stopped tasks, one per distinct
+// description and tags.
+func getRecentTasks(tx *sql.Tx, limit int) ([]Task, error) {
+	return queryTasks(tx, fmt.Sprintf(
+		`SELECT %s FROM Task WHERE ID IN (
+            SELECT ID FROM (
+                SELECT ID, MAX(StartedAt), Description, %s AS TagsKey
+                FROM Task WHERE StoppedAt IS NOT NULL
+                GROUP BY Description, TagsKey
+            )
+        )
+        ORDER BY StartedAt DESC
+        LIMIT ?`,
+		taskProxyFields(),
+		taskTagsSelect(),
+	), limit)
+}
+
+// getOverlappingTasks returns the tasks sharing some time with the given
+// interval, the running task is considered to end now.
+func getOverlappingTasks(tx *sql.Tx, start, end time.Time) ([]Task, error) {
+	return queryTasks(tx, fmt.Sprintf(
+		`SELECT %s FROM Task
+        WHERE StartedAt < ? AND IFNULL(StoppedAt, ?) > ?
+        ORDER BY StartedAt ASC`,
+		taskProxyFields(),
+	), end.Unix(), time.Now().Unix(), start.Unix())
+}
+
+// getLastStoppedTask returns the task that stopped last, or nil if there is
+// none.
+func getLastStoppedTask(tx *sql.Tx) (*Task, error) {
+	tasks, err := queryTasks(tx, fmt.Sprintf(
+		`SELECT %s FROM Task
+        WHERE StoppedAt IS NOT NULL
+        ORDER BY StoppedAt DESC LIMIT 1`,
+		taskProxyFields(),
+	))
+	if err != nil || len(tasks) == 0 {
+		return nil, err
+	}
+
+	return &tasks[0], nil
+}
+
 func getCurrentTask(tx *sql.Tx) (*Task, error) {
 	var proxy taskProxy
 
@@ -188,13 +399,17 @@ func getCurrentTask(tx *sql.Tx) (*Task, error) {
 	return proxy.Task()
 }
 
-func stopTask(tx *sql.Tx, id int64) error {
-	return exec(
+func stopTask(tx *sql.Tx, id int64, at time.Time) error {
+	if err := exec(
 		tx,
 		`UPDATE Task SET StoppedAt = ? WHERE ID = ?`,
-		util.NewNullTimeAsTimestamp(time.Now()),
+		util.NewNullTimeAsTimestamp(at),
 		id,
-	)
+	); err != nil {
+		return err
+	}
+
+	return addTaskHistory(tx, id, historyUpdate)
 }
 
 func deleteTask(tx *sql.Tx, id int64) error {
@@ -202,5 +417,21 @@ func deleteTask(tx *sql.Tx, id int64) error {
 		return ErrInvalidTaskID
 	}
 
-	return exec(tx, `DELETE FROM Task WHERE ID = ?`, id)
+	if err := addTaskHistory(tx, id, historyDelete); err != nil {
+		return err
+	}
+
+	if err := exec(tx, `DELETE FROM TaskTag WHERE TaskID = ?`, id); err != nil {
+		return err
+	}
+
+	if err := exec(tx, `DELETE FROM TaskStack WHERE TaskID = ?`, id); err != nil {
+		return err
+	}
+
+	if err := exec(tx, `DELETE FROM Task WHERE ID = ?`, id); err != nil {
+		return err
+	}
+
+	return deleteUnusedTags(tx)
 }
@@ -6,13 +6,15 @@ import (
 	"tt/internal/util"
 )
 
-// taskProxy is the Task as stored in DB.
+// taskProxy is the Task as stored in DB, Tags is the JSON array built from
+// the TaskTag table when reading and is not used when writing.
 type taskProxy struct {
 	ID          int64
 	Description string
 	Tags        []byte
 	StartedAt   util.TimeAsTimestamp
 	StoppedAt   util.NullTimeAsTimestamp
+	Deadline    util.NullTimeAsTimestamp
 }
 
 type scannable interface {
@@ -21,7 +23,21 @@ type scannable interface {
 
 func taskProxyFields() string {
 	// Order must match fields in taskProxy.Scan
-	return `"ID", "Description", "StartedAt", "StoppedAt", "Tags"`
+	return `"Task"."ID", "Task"."Description", "Task"."StartedAt", "Task"."StoppedAt",
+        "Task"."Deadline", ` + taskTagsSelect() + ` AS "Tags"`
+}
+
+// taskTagsSelect returns a subquery giving the tags of the Task row as a JSON
+// array.
+func taskTagsSelect() string {
+	return `(
+        SELECT json_group_array("Name") FROM (
+            SELECT "Tag"."Name" FROM "TaskTag"
+            JOIN "Tag" ON "Tag"."ID" = "TaskTag"."TagID"
+            WHERE "TaskTag"."TaskID" = "Task"."ID"
+            ORDER BY "TaskTag"."Position" ASC
+        )
+    )`
 }
 
 func (t *taskProxy) scan(s scannable) error {
@@ -31,27 +47,19 @@ func (t *taskProxy) scan(s scannable) error {
 		&t.Description,
 		&t.StartedAt,
 		&t.StoppedAt,
+		&t.Deadline,
 		&t.Tags,
 	)
 }
 
-func newProxyFromTask(t Task) (taskProxy, error) {
-	tags := []byte("[]")
-	if t.Tags != nil {
-		var err error
-		tags, err = json.Marshal(t.Tags)
-		if err != nil {
-			return taskProxy{}, RuntimeError(fmt.Sprintf("unable to encode tags: %s", err))
-		}
-	}
-
+func newProxyFromTask(t Task) taskProxy {
 	return taskProxy{
 		ID:          t.ID,
 		Description: t.Description,
 		StartedAt:   util.TimeAsTimestamp(t.StartedAt),
 		StoppedAt:   util.NewNullTimeAsTimestamp(t.StoppedAt),
-		Tags:        tags,
-	}, nil
+		Deadline:    util.NewNullTimeAsTimestamp(t.Deadline),
+	}
 }
 
 func (t taskProxy) Task() (*Task, error) {
@@ -60,6 +68,7 @@ func (t taskProxy) Task() (*Task, error) {
 		Description: t.Description,
 		StartedAt:   t.StartedAt.Time(),
 		StoppedAt:   t.StoppedAt.Time.Time(),
+		Deadline:    t.Deadline.Time.Time(),
 	}
 
 	if err := json.Unmarshal(t.Tags, &ret.Tags); err != nil {
@@ -0,0 +1,139 @@
+// nolint:thelper
+package tt_test
+
+import (
+	"database/sql"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+	"tt/internal/tt"
+)
+
+// insertRandomTasks inserts count tasks, possibly overlapping, within the
+// days following base on a 30 minutes grid so that bounds often coincide.
+// The last one is running if running is true.
+func insertRandomTasks(t *testing.T, app *tt.TT, rnd *rand.Rand, base time.Time, days, count int, running bool) []tt.Task {
+	slot := func() time.Time {
+		return base.Add(time.Duration(rnd.Intn(days*48)) * 30 * time.Minute)
+	}
+
+	if err := app.ExecSQL(`DELETE FROM Task`); err != nil {
+		t.Fatal(err)
+	}
+
+	tasks := make([]tt.Task, 0, count)
+	for i := 0; i < count; i++ {
+		task := tt.Task{ID: int64(i + 1), Description: "task", StartedAt: slot()}
+		if !running || i < count-1 {
+			// Up to three days long, zero included.
+			task.StoppedAt = task.StartedAt.Add(time.Duration(rnd.Intn(3*48)) * 30 * time.Minute)
+		}
+
+		stop := sql.NullInt64{Int64: task.StoppedAt.Unix(), Valid: task.IsStopped()}
+		if err := app.ExecSQL(
+			`INSERT INTO Task (ID, Description, StartedAt, StoppedAt) VALUES (?, ?, ?, ?)`,
+			task.ID, task.Description, task.StartedAt.Unix(), stop,
+		); err != nil {
+			t.Fatal(err)
+		}
+
+		tasks = append(tasks, task)
+	}
+
+	return tasks
+}
+
+func TestGetTasksInRange(t *testing.T) {
+	app := newTestApp(t)
+	rnd := rand.New(rand.NewSource(1)) // nolint:gosec
+	base := time.Date(2024, 3, 4, 0, 0, 0, 0, time.Local)
+
+	for i := 0; i < 200; i++ {
+		tasks := insertRandomTasks(t, app, rnd, base, 10, 1+rnd.Intn(20), rnd.Intn(2) == 0)
+
+		start := base.Add(time.Duration(rnd.Intn(12*48)-48) * 30 * time.Minute)
+		end := start.Add(time.Duration(rnd.Intn(4*48)) * 30 * time.Minute)
+
+		var expected []int64
+		for _, v := range tasks {
+			if v.StartedAt.Before(end) && (!v.IsStopped() || v.StoppedAt.After(start)) {
+				expected = append(expected, v.ID)
+			}
+		}
+
+		got, err := app.GetTasksInRange(start, end)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ids := make([]int64, 0, len(got))
+		for j, v := range got {
+			ids = append(ids, v.ID)
+			if j > 0 && v.StartedAt.Before(got[j-1].StartedAt) {
+				t.Errorf("expected tasks sorted by start, got %+v", got)
+			}
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+		if len(ids) != len(expected) {
+			t.Fatalf("range %s - %s: expected tasks %v, got %v in %+v", start, end, expected, ids, tasks)
+		}
+		for j := range ids {
+			if ids[j] != expected[j] {
+				t.Fatalf("range %s - %s: expected tasks %v, got %v in %+v", start, end, expected, ids, tasks)
+			}
+		}
+	}
+}
+
+func TestClampTasks(t *testing.T) {
+	app := newTestApp(t)
+	rnd := rand.New(rand.NewSource(1)) // nolint:gosec
+	base := time.Date(2024, 3, 4, 0, 0, 0, 0, time.Local)
+	const days = 10
+
+	for i := 0; i < 50; i++ {
+		tasks := insertRandomTasks(t, app, rnd, base, days, 1+rnd.Intn(20), true)
+		running := tasks[len(tasks)-1]
+
+		// Splitting the stopped tasks on each day must keep their total, the
+		// running one counts until the end of each past day.
+		var expected time.Duration
+		for _, v := range tasks {
+			if v.IsStopped() {
+				expected += v.Duration()
+			}
+		}
+
+		var got time.Duration
+		until := base.AddDate(0, 0, days+3)
+		for day := base; day.Before(until); day = day.AddDate(0, 0, 1) {
+			next := day.AddDate(0, 0, 1)
+
+			dayTasks, err := app.GetTasksInRange(day, next)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, v := range tt.ClampTasks(dayTasks, day, next) {
+				if v.StartedAt.Before(day) || !v.IsStopped() || v.StoppedAt.After(next) {
+					t.Fatalf("task %+v not clamped to %s - %s", v, day, next)
+				}
+
+				if v.ID == running.ID {
+					if !v.StoppedAt.Equal(next) {
+						t.Errorf("expected running task %+v to stop at %s", v, next)
+					}
+					continue
+				}
+
+				got += v.Duration()
+			}
+		}
+
+		if got != expected {
+			t.Errorf("expected a total of %s over the days, got %s in %+v", expected, got, tasks)
+		}
+	}
+}
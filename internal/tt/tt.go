@@ -15,23 +15,102 @@ import (
 )
 
 type TT struct {
-	db *sql.DB
+	db      *sql.DB // deferred transactions, for reads
+	writeDB *sql.DB // immediate transactions, for changes
+
+	migrated bool   // whether the schema is up to date, enabling the journal and history
+	source   string // what is changing the tasks, recorded in their history
 }
 
+// New opens the database and applies pending migrations.
 func New(dsn string) (*TT, error) {
-	db, err := sql.Open("sqlite3", dsn)
+	tt, err := Open(dsn)
 	if err != nil {
-		return nil, IOError{"unable to open database", dsn, err}
+		return nil, err
 	}
 
-	if err := migrate(db); err != nil {
+	if err := tt.Migrate(); err != nil {
+		_ = tt.Close()
 		return nil, IOError{"unable to migrate DB", dsn, err}
 	}
 
-	return &TT{db: db}, nil
+	return tt, nil
+}
+
+// Open opens the database without migrating it, Migrate must be called before
+// using anything else than MigrationStatus.
+func Open(dsn string) (*TT, error) {
+	db, err := sql.Open("sqlite3", withConnParams(dsn, "_journal_mode=WAL", "_busy_timeout=5000"))
+	if err != nil {
+		return nil, IOError{"unable to open database", dsn, err}
+	}
+
+	// Each connection to an in-memory database is a new database, and no other
+	// process can use it anyway.
+	writeDB := db
+	if !isMemoryDSN(dsn) {
+		writeDB, err = sql.Open("sqlite3", withConnParams(dsn, "_journal_mode=WAL", "_busy_timeout=5000", "_txlock=immediate"))
+		if err != nil {
+			_ = db.Close()
+			return nil, IOError{"unable to open database", dsn, err}
+		}
+	}
+
+	return &TT{db: db, writeDB: writeDB, source: SourceAPI}, nil
+}
+
+// withConnParams adds the given go-sqlite3 parameters to the DSN, unless they
+// are already set. They make concurrent tt processes safe: WAL lets readers
+// run while another process writes, the busy timeout waits for locks instead
+// of failing right away, and immediate transactions take the write lock on
+// BEGIN so that two read-then-write transactions (eg. Start reading the
+// current task then inserting the next one) cannot interleave. Only the
+// connections used for changes start immediate transactions, so that reads
+// do not wait for writers.
+func withConnParams(dsn string, params ...string) string {
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+
+	for _, v := range params {
+		key := v[:strings.Index(v, "=")+1]
+		if strings.Contains(dsn, "?"+key) || strings.Contains(dsn, "&"+key) {
+			continue
+		}
+
+		dsn += sep + v
+		sep = "&"
+	}
+
+	return dsn
+}
+
+func isMemoryDSN(dsn string) bool {
+	return strings.HasPrefix(dsn, ":memory:") || strings.HasPrefix(dsn, "file::memory:") ||
+		strings.Contains(dsn, "mode=memory")
+}
+
+// Sources of the changes recorded in the task history.
+const (
+	SourceAPI       = "api"
+	SourceCLI       = "cli"
+	SourceTUI       = "tui"
+	SourceMigration = "migration"
+)
+
+// SetSource tells what makes the next changes, eg. SourceCLI.
+func (tt *TT) SetSource(source string) {
+	tt.source = source
 }
 
 func (tt *TT) Close() error {
+	if tt.writeDB != tt.db {
+		if err := tt.writeDB.Close(); err != nil {
+			return fmt.Errorf("unable to close DB: %w", err)
+		}
+	}
+
 	if err := tt.db.Close(); err != nil {
 		return fmt.Errorf("unable to close DB: %w", err)
 	}
@@ -43,8 +122,28 @@ func (tt *TT) Close() error {
 // or do nothing.
 // It returns the current task (if any) and next task (always). If the task is
 // the same, the data might differ.
-// nolint:cyclop,gocognit // might be TODO
 func (tt *TT) Start(raw string) (*Task, *Task, error) {
+	return tt.StartAt(raw, time.Now())
+}
+
+// StartAt is Start with the new task starting (and the current one stopping)
+// at the given time instead of now.
+func (tt *TT) StartAt(raw string, at time.Time) (*Task, *Task, error) {
+	return tt.startTimeboxed(raw, at, time.Time{})
+}
+
+// StartFor is StartAt with the task planned to end after the given duration,
+// it will be stopped by ExpireTask once its deadline is past. Starting the
+// current task again sets its new deadline.
+func (tt *TT) StartFor(raw string, at time.Time, d time.Duration) (*Task, *Task, error) {
+	if d <= 0 {
+		return nil, nil, InvalidInputError(fmt.Sprintf("invalid timebox duration: %s", d))
+	}
+
+	return tt.startTimeboxed(raw, at, at.Add(d))
+}
+
+func (tt *TT) startTimeboxed(raw string, at, deadline time.Time) (*Task, *Task, error) {
 	desc, tags := ParseRawDesc(raw)
 	var current, next *Task
 
@@ -54,50 +153,144 @@ func (tt *TT) Start(raw string) (*Task, *Task, error) {
 			return err
 		}
 
-		// Same task, maybe update tags.
-		if current != nil && (current.Description == desc || desc == "") {
-			next = &Task{}
-			*next = *current
+		// A different task without tags reuses the current task tags.
+		if len(tags) == 0 && current != nil && desc != "" && current.Description != desc {
+			tags = current.Tags
+		}
 
-			if reflect.DeepEqual(current.Tags, tags) {
-				return ErrContinue
-			}
+		next, err = startTask(tx, current, desc, tags, at, deadline)
+		return err
+	})
 
-			// Tags differ, update current task with new tags.
-			next.Tags = tags
-			if err := next.update(tx); err != nil {
-				return err
-			}
-			return nil
+	return current, next, err
+}
+
+// startTask stops current (if any) and starts a new task at the given time,
+// unless it is the same task in which case only its tags and deadline are
+// updated. A zero deadline means the task is not timeboxed.
+// nolint:cyclop // might be TODO
+func startTask(tx *sql.Tx, current *Task, desc string, tags []string, at, deadline time.Time) (*Task, error) {
+	// Same task, maybe update tags.
+	if current != nil && (current.Description == desc || desc == "") {
+		next := &Task{}
+		*next = *current
+
+		if reflect.DeepEqual(current.Tags, tags) && (deadline.IsZero() || deadline.Equal(current.Deadline)) {
+			return next, ErrContinue
 		}
 
-		if current != nil {
-			if err := stopTask(tx, current.ID); err != nil {
-				return err
-			}
+		// Tags or deadline differ, update current task.
+		next.Tags = tags
+		if !deadline.IsZero() {
+			next.Deadline = deadline
+		}
+		if err := next.update(tx); err != nil {
+			return nil, err
 		}
+		return next, nil
+	}
 
-		if len(tags) == 0 && current != nil {
-			tags = current.Tags
+	if err := checkStartTime(tx, current, at); err != nil {
+		return nil, err
+	}
+
+	if current != nil {
+		if err := stopTask(tx, current.ID, at); err != nil {
+			return nil, err
 		}
+		current.StoppedAt = at
+	}
+
+	if desc == "" {
+		return nil, ErrInvalidTaskDesc
+	}
+
+	next := NewTask(desc, tags)
+	next.StartedAt = at
+	next.Deadline = deadline
+	if err := next.insert(tx); err != nil {
+		return nil, err
+	}
+
+	return next, nil
+}
 
-		if desc == "" {
-			return ErrInvalidTaskDesc
+// checkStartTime ensures a task starting at the given time won't overlap the
+// current task or the previous one.
+func checkStartTime(tx *sql.Tx, current *Task, at time.Time) error {
+	if at.After(time.Now()) {
+		return InvalidInputError(fmt.Sprintf("cannot start a task in the future (%s)", at.Format(timeFormat)))
+	}
+
+	if current != nil {
+		if !at.After(current.StartedAt) {
+			return InvalidInputError(fmt.Sprintf(
+				"the current task started at %s, the next one must start after that",
+				current.StartedAt.Format(timeFormat),
+			))
 		}
 
-		next = NewTask(desc, tags)
-		if err := next.insert(tx); err != nil {
+		return nil
+	}
+
+	prev, err := getLastStoppedTask(tx)
+	if err != nil || prev == nil {
+		return err
+	}
+
+	if at.Before(prev.StoppedAt) {
+		return InvalidInputError(fmt.Sprintf(
+			"the previous task \"%s\" stopped at %s, the next one cannot start before that",
+			prev.Description,
+			prev.StoppedAt.Format(timeFormat),
+		))
+	}
+
+	return nil
+}
+
+// ExpireTask stops the current task at its deadline if it is past.
+// It returns the stopped task, or nil if there was nothing to stop. The stop is
+// not recorded in the journal.
+func (tt *TT) ExpireTask() (*Task, error) {
+	var expired *Task
+
+	if err := tt.unjournaledTransaction(func(tx *sql.Tx) error {
+		cur, err := getCurrentTask(tx)
+		if err != nil {
+			if errors.Is(err, ErrNoCurrentTask) {
+				return nil
+			}
 			return err
 		}
 
-		return nil
-	})
+		if cur.Deadline.IsZero() || cur.Deadline.After(time.Now()) {
+			return nil
+		}
 
-	return current, next, err
+		// A deadline set before the task start (eg. when editing the task)
+		// would make the task end before it started.
+		cur.StoppedAt = cur.Deadline
+		if cur.StoppedAt.Before(cur.StartedAt) {
+			cur.StoppedAt = cur.StartedAt
+		}
+
+		expired = cur
+		return stopTask(tx, cur.ID, cur.StoppedAt)
+	}); err != nil {
+		return nil, err
+	}
+
+	return expired, nil
 }
 
 // Stop stops the current task if any.
 func (tt *TT) Stop() (*Task, error) {
+	return tt.StopAt(time.Now())
+}
+
+// StopAt stops the current task at the given time.
+func (tt *TT) StopAt(at time.Time) (*Task, error) {
 	var cur *Task
 
 	if err := tt.transaction(func(tx *sql.Tx) (err error) {
@@ -106,8 +299,19 @@ func (tt *TT) Stop() (*Task, error) {
 			return err
 		}
 
-		cur.StoppedAt = time.Now()
-		if err := stopTask(tx, cur.ID); err != nil {
+		if at.After(time.Now()) {
+			return InvalidInputError(fmt.Sprintf("cannot stop a task in the future (%s)", at.Format(timeFormat)))
+		}
+
+		if !at.After(cur.StartedAt) {
+			return InvalidInputError(fmt.Sprintf(
+				"the current task started at %s, it must stop after that",
+				cur.StartedAt.Format(timeFormat),
+			))
+		}
+
+		cur.StoppedAt = at
+		if err := stopTask(tx, cur.ID, at); err != nil {
 			return err
 		}
 
@@ -119,6 +323,54 @@ func (tt *TT) Stop() (*Task, error) {
 	return cur, nil
 }
 
+// AddTask creates a stopped task, overlaps with existing tasks are resolved
+// according to the OverlapPolicy rule.
+func (tt *TT) AddTask(raw string, start, stop time.Time) (*Task, error) {
+	policy, err := tt.GetOverlapPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	task, _, err := tt.AddTaskWith(raw, start, stop, policy, false)
+
+	return task, err
+}
+
+// AddTaskWith is AddTask with the given overlap policy, it returns the changes
+// made to the other tasks. If dryRun is true, nothing is saved.
+func (tt *TT) AddTaskWith(
+	raw string, start, stop time.Time, policy OverlapPolicy, dryRun bool,
+) (*Task, []TaskChange, error) {
+	desc, tags := ParseRawDesc(raw)
+	if desc == "" {
+		return nil, nil, InvalidInputError("a task needs a description")
+	}
+
+	if !stop.After(start) {
+		return nil, nil, InvalidInputError("a task must stop after it started")
+	}
+
+	if stop.After(time.Now()) {
+		return nil, nil, InvalidInputError(fmt.Sprintf("cannot add a task ending in the future (%s)", stop.Format(timeFormat)))
+	}
+
+	task := &Task{Description: desc, Tags: tags, StartedAt: start, StoppedAt: stop}
+
+	var changes []TaskChange
+	if err := tt.transactionOrDryRun(dryRun, func(tx *sql.Tx) (err error) {
+		changes, err = resolveOverlaps(tx, *task, policy)
+		if err != nil {
+			return err
+		}
+
+		return task.insert(tx)
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	return task, changes, nil
+}
+
 // ParseRawDesc splits the description and tags from user input.
 // tags can only be provided at the end of the string.
 func ParseRawDesc(raw string) (string, []string) {
@@ -159,20 +411,88 @@ func (tt *TT) GetTasks() ([]Task, error) {
 	return tt.wrapTaskQuery(getAllTasks)
 }
 
+// GetTask returns the task with the given ID.
+func (tt *TT) GetTask(taskID int64) (*Task, error) {
+	var task *Task
+
+	if err := tt.readTransaction(func(tx *sql.Tx) (err error) {
+		task, err = getTask(tx, taskID)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// GetTaskAt returns the task that was running at the given time.
+func (tt *TT) GetTaskAt(at time.Time) (*Task, error) {
+	var task *Task
+
+	if err := tt.readTransaction(func(tx *sql.Tx) error {
+		tasks, err := getOverlappingTasks(tx, at, at.Add(time.Second))
+		if err != nil {
+			return err
+		}
+
+		if len(tasks) == 0 {
+			return InvalidInputError(fmt.Sprintf("there is no task at %s", at.Format(timeFormat)))
+		}
+
+		task = &tasks[0]
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
 func (tt *TT) DeleteTask(taskID int64) error {
 	return tt.transaction(func(tx *sql.Tx) error {
 		return deleteTask(tx, taskID)
 	})
 }
 
+// UpdateTask saves the given task, overlaps with other tasks are resolved
+// according to the OverlapPolicy rule.
 func (tt *TT) UpdateTask(t Task) error {
-	return tt.transaction(t.update)
+	policy, err := tt.GetOverlapPolicy()
+	if err != nil {
+		return err
+	}
+
+	_, err = tt.UpdateTaskWith(t, policy, false)
+
+	return err
+}
+
+// UpdateTaskWith is UpdateTask with the given overlap policy, it returns the
+// changes made to the other tasks. If dryRun is true, nothing is saved.
+func (tt *TT) UpdateTaskWith(t Task, policy OverlapPolicy, dryRun bool) ([]TaskChange, error) {
+	if !t.StoppedAt.IsZero() && !t.StoppedAt.After(t.StartedAt) {
+		return nil, InvalidInputError("a task must stop after it started")
+	}
+
+	var changes []TaskChange
+	if err := tt.transactionOrDryRun(dryRun, func(tx *sql.Tx) (err error) {
+		changes, err = resolveOverlaps(tx, t, policy)
+		if err != nil {
+			return err
+		}
+
+		return t.update(tx)
+	}); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
 }
 
 func (tt *TT) CurrentTask() (*Task, error) {
 	var cur *Task
 
-	if err := tt.transaction(func(tx *sql.Tx) (err error) {
+	if err := tt.readTransaction(func(tx *sql.Tx) (err error) {
 		cur, err = getCurrentTask(tx)
 		return err
 	}); err != nil {
@@ -184,36 +504,77 @@ func (tt *TT) CurrentTask() (*Task, error) {
 
 func (tt *TT) GetDurationLeft() (time.Duration, time.Duration, error) {
 	var daily, weekly time.Duration
-	rules := staticRulesTimeline().forDay(time.Now())
 
-	if err := tt.transaction(func(tx *sql.Tx) (err error) {
-		if rules[ruleWeeklyHours] <= 0 {
-			return ErrNotConfigured
-		}
+	if err := tt.readTransaction(func(tx *sql.Tx) (err error) {
+		daily, weekly, err = tt.getDurationLeft(tx, time.Now())
+		return err
+	}); err != nil {
+		return 0, 0, err
+	}
 
-		now := time.Now()
+	return daily, weekly, nil
+}
 
-		dayStart := util.GetStartOfDay(now)
-		dayEnd := dayStart.AddDate(0, 0, 1)
-		daily, err = tt.getAggregatedTime(tx, dayStart, dayEnd)
-		if err != nil {
-			return err
-		}
+func (tt *TT) getDurationLeft(tx *sql.Tx, now time.Time) (time.Duration, time.Duration, error) {
+	var daily, weekly, dailyTarget, weeklyTarget time.Duration
 
-		weekStart := util.GetStartOfWeek(now)
-		weekEnd := weekStart.AddDate(0, 0, 7)
-		weekly, err = tt.getAggregatedTime(tx, weekStart, weekEnd)
-		if err != nil {
-			return err
-		}
+	timeline, err := getRulesTimeline(tx)
+	if err != nil {
+		return 0, 0, err
+	}
 
-		return nil
-	}); err != nil {
+	rules := timeline.forDay(now)
+	schedule := rules.schedule()
+	if schedule.Total() <= 0 {
+		return 0, 0, ErrNotConfigured
+	}
+
+	dayStart := util.GetStartOfDay(now)
+	dayEnd := dayStart.AddDate(0, 0, 1)
+	daily, err = tt.getAggregatedTime(tx, dayStart, dayEnd)
+	if err != nil {
 		return 0, 0, err
 	}
 
-	weeklyWork := time.Hour * time.Duration((rules[ruleWeeklyHours]))
-	return (weeklyWork / 5) - daily, weeklyWork - weekly, nil
+	weekStart := util.GetStartOfWeek(now)
+	weekEnd := weekStart.AddDate(0, 0, 7)
+	weekly, err = tt.getAggregatedTime(tx, weekStart, weekEnd)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	off, err := getDaysOff(tx, weekStart, weekEnd)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for day := weekStart; day.Before(weekEnd); day = day.AddDate(0, 0, 1) {
+		if off.holidays.contains(day) {
+			continue
+		}
+
+		target := schedule.Weekday(day.Weekday())
+		target -= off.leaves.credit(day, target)
+
+		// Missing days are either done or waived unless they count as a deficit.
+		if day.Before(dayStart) && target > 0 &&
+			int(rules[RuleMissingDayPolicy]) != missingDayDeficit {
+			missing, err := isMissingDay(tx, day, rules, off)
+			if err != nil {
+				return 0, 0, err
+			}
+
+			if missing {
+				target = 0
+			}
+		}
+		if day.Equal(dayStart) {
+			dailyTarget = target
+		}
+		weeklyTarget += target
+	}
+
+	return dailyTarget - daily, weeklyTarget - weekly, nil
 }
 
 func (tt *TT) getAggregatedTime(tx *sql.Tx, start, end time.Time) (time.Duration, error) {
@@ -134,7 +134,140 @@ func testBasicsNewTask(t *testing.T, app *tt.TT) {
 	}
 }
 
-func newTestApp(t *testing.T) *tt.TT {
+func TestStartStopAt(t *testing.T) {
+	app := newTestApp(t)
+	now := time.Now().Truncate(time.Second)
+
+	expectInvalidInput := func(err error) {
+		t.Helper()
+		var inputError tt.InvalidInputError
+		if !errors.As(err, &inputError) {
+			t.Errorf("expected InvalidInputError, got %v", err)
+		}
+	}
+
+	if _, _, err := app.StartAt("first", now.Add(-3*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err := app.StartAt("second", now.Add(-4*time.Hour))
+	expectInvalidInput(err) // before the current task start
+
+	_, _, err = app.StartAt("second", now.Add(time.Hour))
+	expectInvalidInput(err) // in the future
+
+	prev, next, err := app.StartAt("second", now.Add(-2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !prev.StoppedAt.Equal(now.Add(-2*time.Hour)) || !next.StartedAt.Equal(prev.StoppedAt) {
+		t.Errorf("expected tasks to be contiguous, got %v and %v", prev.StoppedAt, next.StartedAt)
+	}
+
+	_, err = app.StopAt(now.Add(-3 * time.Hour))
+	expectInvalidInput(err) // before the task start
+
+	stopped, err := app.StopAt(now.Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stopped.Duration() != time.Hour {
+		t.Errorf("expected a 1h task, got %s", stopped.Duration())
+	}
+
+	_, _, err = app.StartAt("third", now.Add(-90*time.Minute))
+	expectInvalidInput(err) // before the previous task end
+
+	if _, _, err := app.StartAt("third", now.Add(-time.Hour)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAddTask(t *testing.T) {
+	app := newTestApp(t)
+	start := time.Date(2024, 3, 4, 14, 0, 0, 0, time.Local)
+
+	task, err := app.AddTask("meeting @acme", start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.ID == 0 || task.Description != "meeting" || !reflect.DeepEqual(task.Tags, []string{"@acme"}) {
+		t.Errorf("unexpected task: %+v", task)
+	}
+
+	if _, _, err := app.StartAt("running", time.Now().Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name        string
+		raw         string
+		start, stop time.Time
+		ok          bool
+	}{
+		{"adjacent before", "a", start.Add(-time.Hour), start, true},
+		{"adjacent after", "b", start.Add(time.Hour), start.Add(2 * time.Hour), true},
+		{"overlap", "c", start.Add(30 * time.Minute), start.Add(3 * time.Hour), false},
+		{"inside", "c", start.Add(10 * time.Minute), start.Add(20 * time.Minute), false},
+		{"around", "c", start.Add(-2 * time.Hour), start.Add(5 * time.Hour), false},
+		{"running task", "c", time.Now().Add(-30 * time.Minute), time.Now().Add(-20 * time.Minute), false},
+		{"no description", "@acme", start.Add(5 * time.Hour), start.Add(6 * time.Hour), false},
+		{"reversed", "c", start.Add(6 * time.Hour), start.Add(5 * time.Hour), false},
+		{"future", "c", time.Now().Add(time.Hour), time.Now().Add(2 * time.Hour), false},
+	}
+
+	for _, c := range cases {
+		_, err := app.AddTask(c.raw, c.start, c.stop)
+		var inputError tt.InvalidInputError
+		if c.ok && err != nil {
+			t.Errorf("%s: unexpected error: %s", c.name, err)
+		} else if !c.ok && !errors.As(err, &inputError) {
+			t.Errorf("%s: expected InvalidInputError, got %v", c.name, err)
+		}
+	}
+}
+
+func TestStartFor(t *testing.T) {
+	app := newTestApp(t)
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	_, next, err := app.StartFor("meeting @acme", start, 30*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !next.Deadline.Equal(start.Add(30 * time.Minute)) {
+		t.Errorf("unexpected deadline: %s", next.Deadline)
+	}
+
+	expired, err := app.ExpireTask()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expired == nil || !expired.StoppedAt.Equal(next.Deadline) {
+		t.Fatalf("expected the task to be stopped at its deadline, got %+v", expired)
+	}
+	if _, err := app.CurrentTask(); !errors.Is(err, tt.ErrNoCurrentTask) {
+		t.Errorf("expected no current task, got %v", err)
+	}
+
+	// Not expired yet, restarting the same task moves the deadline.
+	if _, _, err := app.StartFor("review", start.Add(40*time.Minute), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	_, next, err = app.StartFor("review", time.Now(), 2*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !next.StartedAt.Equal(start.Add(40*time.Minute)) || next.Deadline.Before(time.Now().Add(time.Hour)) {
+		t.Errorf("expected the same task with a later deadline, got %+v", next)
+	}
+
+	if expired, err := app.ExpireTask(); err != nil || expired != nil {
+		t.Errorf("expected nothing to expire, got %v %v", expired, err)
+	}
+}
+
+func newTestApp(t testing.TB) *tt.TT {
 	app, err := tt.New(":memory:")
 	if err != nil {
 		t.Fatal(err)
@@ -149,6 +282,25 @@ func newTestApp(t *testing.T) *tt.TT {
 	return app
 }
 
+type testTask struct {
+	raw         string
+	start, stop time.Duration
+}
+
+// addTestTasks adds the stopped tasks, their bounds are offsets from day.
+func addTestTasks(t *testing.T, app *tt.TT, day time.Time, tasks ...testTask) []tt.Task {
+	ret := make([]tt.Task, 0, len(tasks))
+	for _, v := range tasks {
+		task, err := app.AddTask(v.raw, day.Add(v.start), day.Add(v.stop))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ret = append(ret, *task)
+	}
+
+	return ret
+}
+
 func TestParseRawDesc(t *testing.T) {
 	cases := []struct {
 		input        string
@@ -0,0 +1,68 @@
+package tt
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Watcher detects changes committed to the database by other processes.
+type Watcher struct {
+	conn    *sql.Conn
+	version int64
+}
+
+// Watch returns a Watcher for the database, it must be closed after use.
+//
+// It holds its own connection, outside of transaction: SQLite's data_version
+// only changes when another connection commits and differs between
+// connections, so it must always be read from the same one. Changes made by
+// this TT go through the other connections of its pool, they are reported as
+// well.
+func (tt *TT) Watch() (*Watcher, error) {
+	conn, err := tt.db.Conn(context.Background())
+	if err != nil {
+		return nil, DatabaseError(err.Error())
+	}
+
+	w := &Watcher{conn: conn}
+	if w.version, err = w.dataVersion(); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Changed returns whether the database changed since the previous call, or
+// since Watch for the first one.
+func (w *Watcher) Changed() (bool, error) {
+	version, err := w.dataVersion()
+	if err != nil {
+		return false, err
+	}
+
+	changed := version != w.version
+	w.version = version
+
+	return changed, nil
+}
+
+// Close releases the connection of the Watcher.
+func (w *Watcher) Close() error {
+	if err := w.conn.Close(); err != nil {
+		return DatabaseError(err.Error())
+	}
+
+	return nil
+}
+
+func (w *Watcher) dataVersion() (int64, error) {
+	query := `PRAGMA data_version`
+
+	var version int64
+	if err := w.conn.QueryRowContext(context.Background(), query).Scan(&version); err != nil {
+		return 0, BadQueryError{err, query, nil}
+	}
+
+	return version, nil
+}
@@ -0,0 +1,59 @@
+// nolint:thelper
+package tt_test
+
+import (
+	"path/filepath"
+	"testing"
+	"tt/internal/tt"
+)
+
+func TestWatch(t *testing.T) {
+	dsn := "file:" + filepath.Join(t.TempDir(), "tt.db") + "?mode=rwc"
+
+	app, err := tt.New(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer app.Close()
+
+	other, err := tt.New(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+
+	w, err := app.Watch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	changed := func() bool {
+		v, err := w.Changed()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	if changed() {
+		t.Error("expected no change right after Watch")
+	}
+
+	if _, err := other.GetOverlapPolicy(); err != nil {
+		t.Fatal(err)
+	}
+	if changed() {
+		t.Error("expected reads not to be reported")
+	}
+
+	if _, _, err := other.Start("foo"); err != nil {
+		t.Fatal(err)
+	}
+	if !changed() {
+		t.Error("expected the other process change to be reported")
+	}
+	if changed() {
+		t.Error("expected the change to be reported once")
+	}
+}
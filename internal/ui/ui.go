@@ -26,8 +26,8 @@ const (
 /* Layout:
    mainFlex {
      mainPages [
-       taskFlex {taskTable, taskForm}
-       configForm
+       taskFlex {taskTable, {taskForm, taskHistory}}
+       rulesFlex {rulesTable, rulesForm}
      ]
      mainFooter
    }
@@ -43,12 +43,21 @@ type UI struct {
 	mainFooter *tview.TextView
 
 	// Task view.
-	taskFlex  *tview.Flex
-	taskTable *tview.Table
-	taskForm  *tview.Form
+	taskFlex    *tview.Flex
+	taskTable   *tview.Table
+	taskForm    *tview.Form
+	taskHistory *tview.TextView
 
 	tasks             []tt.Task
 	selectedTaskIndex int // index in tasks slice
+
+	// Rules view.
+	rulesFlex  *tview.Flex
+	rulesTable *tview.Table
+	rulesForm  *tview.Form
+
+	rules             []tt.RuleEntry
+	selectedRuleIndex int // index in rules slice
 }
 
 func New(tt *tt.TT) *UI {
@@ -60,9 +69,14 @@ func New(tt *tt.TT) *UI {
 		mainFlex:   tview.NewFlex(),
 		mainFooter: tview.NewTextView(),
 
-		taskFlex:  tview.NewFlex(),
-		taskTable: tview.NewTable(),
-		taskForm:  tview.NewForm(),
+		taskFlex:    tview.NewFlex(),
+		taskTable:   tview.NewTable(),
+		taskForm:    tview.NewForm(),
+		taskHistory: tview.NewTextView(),
+
+		rulesFlex:  tview.NewFlex(),
+		rulesTable: tview.NewTable(),
+		rulesForm:  tview.NewForm(),
 	}
 
 	ui.init()
@@ -71,23 +85,94 @@ func New(tt *tt.TT) *UI {
 }
 
 func (ui *UI) Run() error {
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go ui.watchChanges(stop)
+
 	// nolint:wrapcheck
 	return ui.app.Run()
 }
 
 const (
 	pageTasks = "tasks"
+	pageRules = "rules"
+	pageModal = "modal"
 )
 
 func (ui *UI) init() {
 	ui.initMainLayout()
 	ui.initTaskPageLayout()
+	ui.initRulesPageLayout()
 
 	ui.mainPages.AddPage(pageTasks, ui.taskFlex, true, true)
+	ui.mainPages.AddPage(pageRules, ui.rulesFlex, true, false)
 
 	ui.mainPages.SetInputCapture(ui.inputCapture)
 	ui.app.SetRoot(ui.mainFlex, true).SetFocus(ui.taskTable)
 	ui.setActivePage(pageTasks)
+
+	ui.checkForgottenTask()
+}
+
+// showModal displays a message over the current page until one of the buttons
+// is pressed, done is then called with the button label.
+func (ui *UI) showModal(text string, buttons []string, done func(label string)) {
+	focused := ui.app.GetFocus()
+
+	modal := tview.NewModal().
+		SetText(text).
+		AddButtons(buttons).
+		SetDoneFunc(func(_ int, label string) {
+			ui.mainPages.RemovePage(pageModal)
+			ui.app.SetFocus(focused)
+			done(label)
+		})
+
+	ui.mainPages.AddPage(pageModal, modal, false, true)
+	ui.app.SetFocus(modal)
+}
+
+// showInputModal displays a form with the given fields and initial values over
+// the current page, done is called with the values once validated.
+func (ui *UI) showInputModal(title string, labels, values []string, done func(values []string)) {
+	focused := ui.app.GetFocus()
+	form := tview.NewForm()
+
+	hide := func() {
+		ui.mainPages.RemovePage(pageModal)
+		ui.app.SetFocus(focused)
+	}
+
+	for i, v := range labels {
+		form.AddInputField(v, values[i], maxDescLen, nil, nil)
+	}
+
+	form.
+		AddButton(t("OK"), func() {
+			ret := make([]string, 0, len(labels))
+			for i := range labels {
+				ret = append(ret, form.GetFormItem(i).(*tview.InputField).GetText())
+			}
+
+			hide()
+			done(ret)
+		}).
+		AddButton(t("Cancel"), hide).
+		SetCancelFunc(hide)
+	form.SetBorder(true).SetTitle(title)
+
+	// Centered with a fixed size, like tview.Modal.
+	layout := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(form, 2*len(labels)+5, 0, true).
+			AddItem(nil, 0, 1, false), maxDescLen+20, 0, true).
+		AddItem(nil, 0, 1, false)
+
+	ui.mainPages.AddPage(pageModal, layout, true, true)
+	ui.app.SetFocus(form)
 }
 
 func (ui *UI) setActivePage(name string) {
@@ -96,9 +181,24 @@ func (ui *UI) setActivePage(name string) {
 }
 
 func (ui *UI) inputCapture(event *tcell.EventKey) *tcell.EventKey {
+	if ui.mainPages.HasPage(pageModal) {
+		return event
+	}
+
 	switch event.Key() { // nolint:exhaustive
 	case tcell.KeyF1:
 		ui.setActivePage(pageTasks)
+		ui.app.SetFocus(ui.taskTable)
+		return nil
+	case tcell.KeyF2:
+		ui.setActivePage(pageRules)
+		ui.app.SetFocus(ui.rulesTable)
+		return nil
+	case tcell.KeyCtrlZ:
+		ui.replayJournal(true)
+		return nil
+	case tcell.KeyCtrlY:
+		ui.replayJournal(false)
 		return nil
 	}
 
@@ -110,6 +210,13 @@ func (ui *UI) inputCapture(event *tcell.EventKey) *tcell.EventKey {
 			ui.app.SetFocus(ui.taskTable)
 			return nil
 		}
+	case ui.rulesTable.HasFocus():
+		return ui.rulesTableInputCapture(event)
+	case ui.rulesForm.HasFocus():
+		if event.Key() == tcell.KeyEscape {
+			ui.app.SetFocus(ui.rulesTable)
+			return nil
+		}
 	}
 
 	return event
@@ -152,6 +259,7 @@ func (ui *UI) initMainLayout() {
 
 	pages := []struct{ name, title, key string }{
 		{pageTasks, t("Tasks"), "F1"},
+		{pageRules, t("Rules"), "F2"},
 	}
 	for _, v := range pages {
 		fmt.Fprintf(ui.mainFooter, `%s ["%s"][darkcyan]%s[white][""]  `, v.key, v.name, v.title)
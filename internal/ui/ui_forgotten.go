@@ -0,0 +1,41 @@
+package ui
+
+import (
+	"fmt"
+	"tt/internal/util"
+)
+
+// checkForgottenTask offers to truncate the running task if it was most likely
+// not stopped in time.
+func (ui *UI) checkForgottenTask() {
+	task, err := ui.tt.GetForgottenCurrentTask()
+	if err != nil {
+		ui.printError("error: unable to check forgotten tasks: %s", err)
+		return
+	}
+	if task == nil {
+		return
+	}
+
+	stop := task.SuggestedStop.Format(dateTimeFormat)
+	text := fmt.Sprintf(
+		t("Task \"%s\" has been running since %s (%s), it was probably not stopped.\nStop it at %s?"),
+		task.Description,
+		task.StartedAt.Format(dateTimeFormat),
+		util.FormatDuration(task.Duration()),
+		stop,
+	)
+
+	ui.showModal(text, []string{t("Stop at ") + stop, t("Keep running")}, func(label string) {
+		if label != t("Stop at ")+stop {
+			return
+		}
+
+		if _, err := ui.tt.FixForgottenTask(task.ID, task.SuggestedStop); err != nil {
+			ui.printError("error: can't stop the task: %s", err)
+			return
+		}
+
+		ui.reloadTasks()
+	})
+}
@@ -0,0 +1,41 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"tt/internal/tt"
+)
+
+// updateTaskHistory shows the changes made to the task, the most recent
+// first.
+func (ui *UI) updateTaskHistory(task tt.Task) {
+	ui.taskHistory.Clear()
+	if task.ID == 0 {
+		return
+	}
+
+	entries, err := ui.tt.GetTaskHistory(task.ID)
+	if errors.Is(err, tt.ErrInvalidTaskID) {
+		return
+	}
+	if err != nil {
+		ui.printError("error: unable to read the task history: %s", err)
+		return
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		v := entries[i]
+		fmt.Fprintf(
+			ui.taskHistory,
+			"%s  %s  %s\n  %s  %s %s\n",
+			v.ChangedAt.Format(dateTimeFormat),
+			v.Kind,
+			v.Source,
+			formatTaskSpan(v.Task),
+			v.Task.Description,
+			strings.Join(v.Task.Tags, " "),
+		)
+	}
+	ui.taskHistory.ScrollToBeginning()
+}
@@ -0,0 +1,27 @@
+package ui
+
+import (
+	"errors"
+	"tt/internal/tt"
+)
+
+// replayJournal undoes or redoes the last change and reloads everything.
+func (ui *UI) replayJournal(undo bool) {
+	var err error
+	if undo {
+		_, err = ui.tt.Undo()
+	} else {
+		_, err = ui.tt.Redo()
+	}
+
+	if errors.Is(err, tt.ErrNothingToUndo) || errors.Is(err, tt.ErrNothingToRedo) {
+		return
+	}
+	if err != nil {
+		ui.printError("error: can't replay the journal: %s", err) // TODO proper error display
+		return
+	}
+
+	ui.reloadTasks()
+	ui.reloadRulesTable()
+}
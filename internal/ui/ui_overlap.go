@@ -0,0 +1,87 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"tt/internal/tt"
+	"tt/internal/util"
+)
+
+// saveTask updates the task, when it overlaps other tasks the changes are
+// shown first and applied only once confirmed.
+func (ui *UI) saveTask(task tt.Task, policy tt.OverlapPolicy) {
+	changes, err := ui.tt.UpdateTaskWith(task, policy, true)
+
+	var overlapErr tt.OverlapError
+	if errors.As(err, &overlapErr) && policy == tt.OverlapReject {
+		ui.askOverlapPolicy(task, overlapErr)
+		return
+	}
+	if err != nil {
+		ui.printError("error: can't update: %s", err) // TODO proper error display
+		return
+	}
+
+	if len(changes) == 0 {
+		ui.applyTask(task, policy)
+		return
+	}
+
+	text := t("The following tasks overlap and will be changed:\n\n") + formatTaskChanges(changes)
+	ui.showModal(text, []string{t("Apply"), t("Cancel")}, func(label string) {
+		if label == t("Apply") {
+			ui.applyTask(task, policy)
+		}
+	})
+}
+
+func (ui *UI) askOverlapPolicy(task tt.Task, err tt.OverlapError) {
+	text := fmt.Sprintf(
+		t("This change overlaps \"%s\" (%s).\nTrim or shift the other tasks?"),
+		err.Task.Description,
+		formatTaskSpan(err.Task),
+	)
+	ui.showModal(text, []string{t("Trim"), t("Shift"), t("Cancel")}, func(label string) {
+		switch label {
+		case t("Trim"):
+			ui.saveTask(task, tt.OverlapTrim)
+		case t("Shift"):
+			ui.saveTask(task, tt.OverlapShift)
+		}
+	})
+}
+
+func (ui *UI) applyTask(task tt.Task, policy tt.OverlapPolicy) {
+	if _, err := ui.tt.UpdateTaskWith(task, policy, false); err != nil {
+		ui.printError("error: can't update: %s", err) // TODO proper error display
+		return
+	}
+
+	ui.reloadTasks()
+}
+
+func formatTaskChanges(changes []tt.TaskChange) string {
+	var b strings.Builder
+
+	for _, v := range changes {
+		if v.After == nil {
+			fmt.Fprintf(&b, t("\"%s\" %s will be deleted\n"), v.Before.Description, formatTaskSpan(v.Before))
+			continue
+		}
+
+		fmt.Fprintf(
+			&b,
+			t("\"%s\" %s -> %s\n"),
+			v.Before.Description,
+			formatTaskSpan(v.Before),
+			formatTaskSpan(*v.After),
+		)
+	}
+
+	return b.String()
+}
+
+func formatTaskSpan(task tt.Task) string {
+	return util.FormatSpan(task.StartedAt, task.StoppedAt, t("now"))
+}
@@ -0,0 +1,119 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+	"tt/internal/tt"
+)
+
+const refreshInterval = time.Second
+
+// watchChanges reloads the tasks and rules when the database is changed by
+// another process, until stop is closed. The reload is delayed while a form
+// or a modal is in use, not to discard what is being typed.
+func (ui *UI) watchChanges(stop <-chan struct{}) {
+	w, err := ui.tt.Watch()
+	if err != nil {
+		ui.printError("error: unable to watch the database: %s", err)
+		return
+	}
+	defer w.Close()
+
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+
+	var pending bool
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		changed, err := w.Changed()
+		if err != nil {
+			continue // eg. busy, try again on the next tick
+		}
+
+		if pending = pending || changed; !pending {
+			continue
+		}
+
+		done := make(chan bool, 1)
+		ui.app.QueueUpdateDraw(func() {
+			done <- ui.refresh()
+		})
+
+		select {
+		case <-stop:
+			return
+		case refreshed := <-done:
+			pending = !refreshed
+		}
+	}
+}
+
+// refresh reloads the tasks and rules, unless a form or a modal is in use,
+// and returns whether it did.
+func (ui *UI) refresh() bool {
+	if ui.taskForm.HasFocus() || ui.rulesForm.HasFocus() || ui.mainPages.HasPage(pageModal) {
+		return false
+	}
+
+	ui.reloadTasks()
+	ui.reloadRulesTable()
+
+	return true
+}
+
+// checkConflict calls save, unless the selected task was changed by another
+// process since it was loaded, in which case the user is asked first.
+func (ui *UI) checkConflict(save func()) {
+	selected, err := ui.selectedTask()
+	if err != nil {
+		return
+	}
+
+	current, err := ui.tt.GetTask(selected.ID)
+	if errors.Is(err, tt.ErrInvalidTaskID) {
+		ui.showModal(t("This task was deleted by another process."), []string{t("OK")}, func(string) {
+			ui.reloadTasks()
+		})
+		return
+	}
+	if err != nil {
+		ui.printError("error: can't update: %s", err) // TODO proper error display
+		return
+	}
+
+	if sameTask(selected, *current) {
+		save()
+		return
+	}
+
+	text := fmt.Sprintf(
+		t("This task was changed by another process since it was loaded, it is now:\n\n\"%s\" %s %s\n\nOverwrite it with your changes?"),
+		current.Description,
+		formatTaskSpan(*current),
+		strings.Join(current.Tags, " "),
+	)
+	ui.showModal(text, []string{t("Overwrite"), t("Reload"), t("Cancel")}, func(label string) {
+		switch label {
+		case t("Overwrite"):
+			save()
+		case t("Reload"):
+			ui.reloadTasks()
+		}
+	})
+}
+
+func sameTask(a, b tt.Task) bool {
+	return a.Description == b.Description &&
+		a.StartedAt.Equal(b.StartedAt) &&
+		a.StoppedAt.Equal(b.StoppedAt) &&
+		a.Deadline.Equal(b.Deadline) &&
+		reflect.DeepEqual(a.Tags, b.Tags)
+}
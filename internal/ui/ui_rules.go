@@ -0,0 +1,209 @@
+package ui
+
+import (
+	"time"
+	"tt/internal/tt"
+	"tt/internal/util"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+func (ui *UI) initRulesPageLayout() {
+	ui.rulesFlex.
+		AddItem(ui.rulesTable, 0, 2, true).
+		AddItem(ui.rulesForm, 0, 1, true)
+
+	ui.initRulesTable()
+	ui.initRulesForm()
+}
+
+func (ui *UI) rulesTableInputCapture(event *tcell.EventKey) *tcell.EventKey {
+	switch event.Key() { // nolint:exhaustive
+	case tcell.KeyRune:
+		switch event.Rune() {
+		case 'q':
+			ui.app.Stop()
+		default:
+			return event
+		}
+	case tcell.KeyEscape:
+		ui.app.Stop()
+	case tcell.KeyDelete:
+		ui.deleteSelectedRule()
+	case tcell.KeyF5:
+		ui.reloadRulesTable()
+	default:
+		return event
+	}
+
+	return nil
+}
+
+func (ui *UI) initRulesTable() {
+	ui.rulesTable.SetBorder(true).SetTitle(t("Rules"))
+	ui.rulesTable.SetSelectable(true, false).SetFixed(1, 0)
+	ui.rulesTable.SetSeparator(tview.Borders.Vertical)
+
+	ui.rulesTable.SetSelectionChangedFunc(func(row, col int) {
+		ui.selectedRuleIndex = row - 1
+	})
+
+	ui.rulesTable.SetSelectedFunc(func(row, col int) {
+		ui.app.SetFocus(ui.rulesForm)
+	})
+
+	ui.reloadRulesTable()
+}
+
+const ( // must match the AddXXX order below
+	rulesFormFieldIndexRule = iota
+	rulesFormFieldIndexValue
+	rulesFormFieldIndexDate
+)
+
+func (ui *UI) initRulesForm() {
+	options := make([]string, 0, len(tt.Rules))
+	for _, v := range tt.Rules {
+		options = append(options, string(v))
+	}
+
+	ui.rulesForm.
+		AddDropDown(t("Rule"), options, 0, nil).
+		AddInputField(t("Value"), "", 0, nil, nil).
+		AddInputField(t("Date"), time.Now().Format(dateFormat), len(dateFormat), nil, nil).
+		AddButton(t("Add"), ui.addFormRule).
+		AddButton(t("End selected"), ui.endSelectedRule)
+
+	ui.rulesForm.SetBorder(true).SetTitle(t("New rule"))
+}
+
+func (ui *UI) rulesFormValue(i int) string {
+	return ui.rulesForm.GetFormItem(i).(*tview.InputField).GetText()
+}
+
+func (ui *UI) rulesFormDate() (time.Time, error) {
+	str := ui.rulesFormValue(rulesFormFieldIndexDate)
+	if str == "" {
+		return time.Time{}, nil
+	}
+
+	day, err := util.ParseTime(str, time.Now())
+	if err != nil {
+		return time.Time{}, tt.InvalidInputError(err.Error())
+	}
+
+	return util.GetStartOfDay(day), nil
+}
+
+func (ui *UI) addFormRule() {
+	ui.app.SetFocus(ui.rulesTable)
+
+	_, name := ui.rulesForm.GetFormItem(rulesFormFieldIndexRule).(*tview.DropDown).GetCurrentOption()
+	rule, err := tt.ParseRule(name)
+	if err != nil {
+		ui.printError("error: invalid rule: %s", err) // TODO proper error display
+		return
+	}
+
+	value, err := tt.ParseRuleValue(rule, ui.rulesFormValue(rulesFormFieldIndexValue))
+	if err != nil {
+		ui.printError("error: invalid value: %s", err) // TODO proper error display
+		return
+	}
+
+	from, err := ui.rulesFormDate()
+	if err != nil {
+		ui.printError("error: invalid date: %s", err) // TODO proper error display
+		return
+	}
+
+	if _, err := ui.tt.AddRule(rule, value, from); err != nil {
+		ui.printError("error: can't add rule: %s", err) // TODO proper error display
+		return
+	}
+
+	ui.reloadRulesTable()
+}
+
+func (ui *UI) selectedRule() (tt.RuleEntry, error) {
+	if len(ui.rules) == 0 || ui.selectedRuleIndex < 0 {
+		return tt.RuleEntry{}, errNoSelection
+	}
+
+	return ui.rules[ui.selectedRuleIndex], nil
+}
+
+func (ui *UI) endSelectedRule() {
+	ui.app.SetFocus(ui.rulesTable)
+	selected, err := ui.selectedRule()
+	if err != nil {
+		return
+	}
+
+	at, err := ui.rulesFormDate()
+	if err != nil || at.IsZero() {
+		ui.printError("error: invalid date: %s", err) // TODO proper error display
+		return
+	}
+
+	if err := ui.tt.EndRule(selected.ID, at); err != nil {
+		ui.printError("error: can't end rule: %s", err) // TODO proper error display
+		return
+	}
+
+	ui.reloadRulesTable()
+}
+
+func (ui *UI) deleteSelectedRule() {
+	selected, err := ui.selectedRule()
+	if err != nil {
+		return
+	}
+
+	if err := ui.tt.DeleteRule(selected.ID); err != nil {
+		ui.printError("error: can't delete rule: %s", err) // TODO proper error display
+		return
+	}
+
+	ui.reloadRulesTable()
+}
+
+func (ui *UI) reloadRulesTable() {
+	rules, err := ui.tt.GetRules()
+	if err != nil {
+		ui.printError("error: unable to read rules: %s", err)
+		rules = nil
+	}
+
+	ui.rules = rules
+
+	ui.rulesTable.Clear()
+	for i, v := range []string{t("Rule"), t("Value"), t("From"), t("Until")} {
+		cell := tview.NewTableCell(v).SetAttributes(tcell.AttrBold)
+		cell.NotSelectable = true
+		ui.rulesTable.SetCell(0, i, cell)
+	}
+
+	day := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+
+		return t.Format(dateFormat)
+	}
+
+	for i, v := range rules {
+		ui.rulesTable.SetCell(i+1, 0, tview.NewTableCell(string(v.Rule)))
+		ui.rulesTable.SetCell(i+1, 1, tview.NewTableCell(tt.FormatRuleValue(v.Rule, v.Value)).SetAlign(tview.AlignRight))
+		ui.rulesTable.SetCell(i+1, 2, tview.NewTableCell(day(v.Start)).SetAlign(tview.AlignRight))
+		ui.rulesTable.SetCell(i+1, 3, tview.NewTableCell(day(v.End)).SetAlign(tview.AlignRight))
+	}
+
+	if max := len(rules) - 1; ui.selectedRuleIndex > max {
+		ui.selectedRuleIndex = max
+	}
+	if len(rules) > 0 {
+		ui.rulesTable.Select(ui.selectedRuleIndex+1, 0)
+	}
+}
@@ -0,0 +1,79 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"tt/internal/tt"
+	"tt/internal/util"
+)
+
+// splitSelectedTask asks when to split the selected task and what the second
+// part is.
+func (ui *UI) splitSelectedTask() {
+	selected, err := ui.selectedTask()
+	if err != nil {
+		return
+	}
+
+	end := selected.StoppedAt
+	if end.IsZero() {
+		end = time.Now()
+	}
+	middle := selected.StartedAt.Add(end.Sub(selected.StartedAt) / 2).Truncate(time.Minute)
+
+	labels := []string{t("Split at"), t("Second part")}
+	values := []string{
+		middle.Format(timeFormat),
+		strings.TrimSpace(selected.Description + " " + strings.Join(selected.Tags, " ")),
+	}
+
+	ui.showInputModal(t("Split task"), labels, values, func(values []string) {
+		at, err := util.ParseTimeOn(values[0], time.Now(), selected.StartedAt)
+		if err != nil {
+			ui.printError("error: invalid time: %s", err) // TODO proper error display
+			return
+		}
+
+		desc, tags := tt.ParseRawDesc(values[1])
+		if _, _, err := ui.tt.SplitTask(selected.ID, at, desc, tags); err != nil {
+			ui.printError("error: can't split: %s", err) // TODO proper error display
+			return
+		}
+
+		ui.reloadTasks()
+	})
+}
+
+// mergeSelectedTask merges the selected task with the one that follows it.
+func (ui *UI) mergeSelectedTask() {
+	selected, err := ui.selectedTask()
+	if err != nil || ui.selectedTaskIndex == 0 {
+		return
+	}
+
+	// Tasks are listed from the most recent.
+	next := ui.tasks[ui.selectedTaskIndex-1]
+	text := fmt.Sprintf(
+		t("Merge \"%s\" (%s) with the following task \"%s\" (%s)?"),
+		selected.Description,
+		formatTaskSpan(selected),
+		next.Description,
+		formatTaskSpan(next),
+	)
+
+	ui.showModal(text, []string{t("Merge"), t("Cancel")}, func(label string) {
+		if label != t("Merge") {
+			return
+		}
+
+		if _, err := ui.tt.MergeTasks([]int64{selected.ID, next.ID}); err != nil {
+			ui.printError("error: can't merge: %s", err) // TODO proper error display
+			return
+		}
+
+		// the merged task takes the place of the following one
+		ui.selectedTaskIndex--
+		ui.reloadTasks()
+	})
+}
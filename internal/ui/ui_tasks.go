@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"errors"
 	"strings"
 	"time"
 	"tt/internal/tt"
@@ -11,12 +12,18 @@ import (
 )
 
 func (ui *UI) initTaskPageLayout() {
+	side := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(ui.taskForm, 0, 1, true).
+		AddItem(ui.taskHistory, 0, 1, false)
+
 	ui.taskFlex.
 		AddItem(ui.taskTable, 0, 2, true).
-		AddItem(ui.taskForm, 0, 1, true)
+		AddItem(side, 0, 1, true)
 
-	ui.initTaskTable()
 	ui.taskForm.SetBorder(true).SetTitle(t("Selected task"))
+	ui.taskHistory.SetBorder(true).SetTitle(t("History"))
+	ui.initTaskTable()
 }
 
 func (ui *UI) taskTableInputCapture(event *tcell.EventKey) *tcell.EventKey {
@@ -25,6 +32,10 @@ func (ui *UI) taskTableInputCapture(event *tcell.EventKey) *tcell.EventKey {
 		switch event.Rune() {
 		case 'q':
 			ui.app.Stop()
+		case 's':
+			ui.splitSelectedTask()
+		case 'm':
+			ui.mergeSelectedTask()
 		default:
 			return event
 		}
@@ -33,7 +44,7 @@ func (ui *UI) taskTableInputCapture(event *tcell.EventKey) *tcell.EventKey {
 	case tcell.KeyDelete:
 		ui.deleteSelectedTask()
 	case tcell.KeyF5:
-		ui.updateTasksTable(ui.tasks)
+		ui.reloadTasks()
 	default:
 		return event
 	}
@@ -85,6 +96,8 @@ func (ui *UI) updateTaskForm(task tt.Task) {
 		AddInputField(t("Tags"), strings.Join(task.Tags, " "), 0, nil, nil).
 		AddButton(t("Save"), ui.saveTaskFormTask).
 		AddButton(t("Delete"), ui.deleteSelectedTask)
+
+	ui.updateTaskHistory(task)
 }
 
 func (ui *UI) getTaskFormTask() (tt.Task, error) {
@@ -100,15 +113,15 @@ func (ui *UI) getTaskFormTask() (tt.Task, error) {
 
 	_, tags := tt.ParseRawDesc(value(taskFormFieldIndexTags))
 
-	location := time.Now().Location()
-	startedAt, err := time.ParseInLocation(formDateTimeFormat, value(taskFormFieldIndexStartedAt), location)
+	now := time.Now()
+	startedAt, err := util.ParseTime(value(taskFormFieldIndexStartedAt), now)
 	if err != nil {
 		return tt.Task{}, tt.InvalidInputError(err.Error())
 	}
 
 	var stoppedAt time.Time
 	if str := value(taskFormFieldIndexStoppedAt); str != "" {
-		stoppedAt, err = time.ParseInLocation(formDateTimeFormat, str, location)
+		stoppedAt, err = util.ParseTimeOn(str, now, startedAt)
 		if err != nil {
 			return tt.Task{}, tt.InvalidInputError(err.Error())
 		}
@@ -139,13 +152,15 @@ func (ui *UI) saveTaskFormTask() {
 		return
 	}
 
-	if err := ui.tt.UpdateTask(task); err != nil {
-		ui.printError("error: can't update: %s", err) // TODO proper error display
+	policy, err := ui.tt.GetOverlapPolicy()
+	if err != nil {
+		ui.printError("error: can't update: %s", err)
 		return
 	}
 
-	ui.tasks[ui.selectedTaskIndex] = task
-	ui.updateTasksTable(ui.tasks)
+	ui.checkConflict(func() {
+		ui.saveTask(task, policy)
+	})
 }
 
 func (ui *UI) selectedTask() (tt.Task, error) {
@@ -184,6 +199,33 @@ func (ui *UI) deleteSelectedTask() {
 	ui.taskTable.Select(ui.selectedTaskIndex+1, 0)
 }
 
+// reloadTasks reads the tasks from the DB again and keeps the selection, on
+// the same task if it still exists.
+func (ui *UI) reloadTasks() {
+	tasks, err := ui.tt.GetTasks()
+	if err != nil && !errors.Is(err, tt.ErrNoTasks) {
+		ui.printError("error: unable to read tasks: %s", err)
+		return
+	}
+
+	selected, err := ui.selectedTask()
+	ui.updateTasksTable(tasks)
+
+	for i, v := range tasks {
+		if err == nil && v.ID == selected.ID {
+			ui.selectedTaskIndex = i
+			break
+		}
+	}
+
+	if max := len(tasks) - 1; ui.selectedTaskIndex > max {
+		ui.selectedTaskIndex = max
+	}
+
+	// let the selection callback update the rest
+	ui.taskTable.Select(ui.selectedTaskIndex+1, 0)
+}
+
 func (ui *UI) updateTasksTable(tasks []tt.Task) {
 	ui.tasks = tasks
 
@@ -0,0 +1,141 @@
+package util
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ICSEvent is the subset of an iCalendar VEVENT needed to import days off.
+type ICSEvent struct {
+	Summary string
+	Start   time.Time
+	End     time.Time // exclusive, zero value if the event has no end
+}
+
+// ParseICS reads the events of an iCalendar (RFC 5545) stream, dates without
+// a timezone are parsed in the given location.
+func ParseICS(r io.Reader, loc *time.Location) ([]ICSEvent, error) {
+	lines, err := unfoldICSLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		ret     []ICSEvent
+		current *ICSEvent
+	)
+
+	for i, line := range lines {
+		name, params, value := splitICSLine(line)
+
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			current = &ICSEvent{}
+		case name == "END" && value == "VEVENT":
+			if current == nil {
+				return nil, fmt.Errorf("line %d: END:VEVENT without BEGIN:VEVENT", i+1) // nolint:goerr113
+			}
+			if current.Start.IsZero() {
+				return nil, fmt.Errorf("line %d: event without DTSTART", i+1) // nolint:goerr113
+			}
+
+			ret = append(ret, *current)
+			current = nil
+		case current == nil:
+			continue
+		case name == "SUMMARY":
+			current.Summary = unescapeICSText(value)
+		case name == "DTSTART", name == "DTEND":
+			t, err := parseICSTime(params, value, loc)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+
+			if name == "DTSTART" {
+				current.Start = t
+			} else {
+				current.End = t
+			}
+		}
+	}
+
+	return ret, nil
+}
+
+// unfoldICSLines joins the lines that were split with a leading whitespace.
+func unfoldICSLines(r io.Reader) ([]string, error) {
+	var ret []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(ret) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			ret[len(ret)-1] += line[1:]
+			continue
+		}
+
+		ret = append(ret, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read calendar: %w", err)
+	}
+
+	return ret, nil
+}
+
+// splitICSLine splits "NAME;PARAM=VALUE:value" lines.
+func splitICSLine(line string) (name string, params map[string]string, value string) {
+	sep := strings.IndexByte(line, ':')
+	if sep < 0 {
+		return strings.ToUpper(line), nil, ""
+	}
+
+	parts := strings.Split(line[:sep], ";")
+	params = make(map[string]string, len(parts)-1)
+	for _, v := range parts[1:] {
+		if eq := strings.IndexByte(v, '='); eq > 0 {
+			params[strings.ToUpper(v[:eq])] = v[eq+1:]
+		}
+	}
+
+	return strings.ToUpper(parts[0]), params, line[sep+1:]
+}
+
+// parseICSTime parses a DATE or DATE-TIME value, UTC and TZID times are
+// converted to the given location so that they fall on the right local day.
+func parseICSTime(params map[string]string, value string, loc *time.Location) (time.Time, error) {
+	zone := loc
+	if tzid, ok := params["TZID"]; ok {
+		if tz, err := time.LoadLocation(tzid); err == nil {
+			zone = tz
+		}
+	}
+
+	layouts := []string{"20060102T150405Z", "20060102T150405", "20060102"}
+	for _, layout := range layouts {
+		var (
+			t   time.Time
+			err error
+		)
+
+		if strings.HasSuffix(layout, "Z") {
+			t, err = time.Parse(layout, value)
+		} else {
+			t, err = time.ParseInLocation(layout, value, zone)
+		}
+
+		if err == nil {
+			return t.In(loc), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date: %s", value) // nolint:goerr113
+}
+
+func unescapeICSText(v string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(v)
+}
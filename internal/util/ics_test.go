@@ -0,0 +1,64 @@
+package util_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"tt/internal/util"
+)
+
+const testICS = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"DTSTART;VALUE=DATE:20240101\r\n" +
+	"DTEND;VALUE=DATE:20240102\r\n" +
+	"SUMMARY:Jour de l'an\\, férié\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"DTSTART:20240814T220000Z\r\n" +
+	"SUMMARY:Assomp\r\n" +
+	" tion\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParseICS(t *testing.T) {
+	events, err := util.ParseICS(strings.NewReader(testICS), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+
+	if events[0].Summary != "Jour de l'an, férié" {
+		t.Errorf("unexpected summary: %s", events[0].Summary)
+	}
+	if !events[0].Start.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected start: %s", events[0].Start)
+	}
+	if !events[0].End.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected end: %s", events[0].End)
+	}
+
+	if events[1].Summary != "Assomption" {
+		t.Errorf("unexpected folded summary: %s", events[1].Summary)
+	}
+	if !events[1].End.IsZero() {
+		t.Errorf("expected no end, got %s", events[1].End)
+	}
+
+	// UTC times fall on the local day.
+	paris := time.FixedZone("CEST", 2*3600)
+	events, err = util.ParseICS(strings.NewReader(testICS), paris)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if start := events[1].Start; start.Location() != paris || !util.GetStartOfDay(start).Equal(time.Date(2024, 8, 15, 0, 0, 0, 0, paris)) {
+		t.Errorf("expected the UTC event on 2024-08-15 local, got %s", start)
+	}
+
+	if _, err := util.ParseICS(strings.NewReader("BEGIN:VEVENT\nDTSTART:foo\nEND:VEVENT\n"), time.UTC); err == nil {
+		t.Error("expected an error on invalid date")
+	}
+}
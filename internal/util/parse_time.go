@@ -0,0 +1,255 @@
+package util
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// nolint:gochecknoglobals
+var (
+	clockRegexp    = regexp.MustCompile(`^(\d{1,2}):(\d{2})(?::(\d{2}))?(am|pm)?$`)
+	hourRegexp     = regexp.MustCompile(`^(\d{1,2})h(\d{2})?$`)
+	meridiemRegexp = regexp.MustCompile(`^(\d{1,2})(am|pm)$`)
+	hoursMinRegexp = regexp.MustCompile(`^(\d+)h(\d+)$`)
+	numberRegexp   = regexp.MustCompile(`^\d+$`)
+
+	isoLayouts = []string{
+		"2006-01-02T15:04:05",
+		"2006-01-02T15:04",
+		"2006-01-02 15:04:05",
+		"2006-01-02 15:04",
+		"2006-01-02",
+	}
+
+	weekdays = map[string]time.Weekday{
+		"mon": time.Monday, "monday": time.Monday,
+		"tue": time.Tuesday, "tuesday": time.Tuesday,
+		"wed": time.Wednesday, "wednesday": time.Wednesday,
+		"thu": time.Thursday, "thursday": time.Thursday,
+		"fri": time.Friday, "friday": time.Friday,
+		"sat": time.Saturday, "saturday": time.Saturday,
+		"sun": time.Sunday, "sunday": time.Sunday,
+	}
+)
+
+// TimeParseError is returned when a user-provided time cannot be understood.
+type TimeParseError struct {
+	Input  string
+	Reason string
+}
+
+func (e TimeParseError) Error() string {
+	return fmt.Sprintf(
+		`invalid time "%s": %s (expected eg. "14:00", "9h30", "yesterday 14:00", "mon 9h", "noon", "2h ago", "-15m" or "2024-03-04 14:00")`,
+		e.Input, e.Reason,
+	)
+}
+
+// ParseTime parses a user-provided time relative to now and in its location.
+// It understands ISO-8601 dates and times, relative durations ("2h ago",
+// "-15m", "in 1h30"), and a day ("today", "yesterday", "tomorrow", a weekday
+// meaning its last occurrence, or YYYY-MM-DD) followed by a time of day
+// ("14:00", "9h", "9h30", "2pm", "noon", "midnight"). Either part is optional,
+// a day alone means its midnight and a time alone means today.
+func ParseTime(str string, now time.Time) (time.Time, error) {
+	return ParseTimeOn(str, now, now)
+}
+
+// ParseTimeOn is ParseTime with times of day without a day falling on the
+// given day instead of today.
+func ParseTimeOn(str string, now, day time.Time) (time.Time, error) {
+	input := strings.Join(strings.Fields(str), " ")
+	if input == "" {
+		return time.Time{}, TimeParseError{str, "empty time"}
+	}
+
+	if v, err := time.Parse(time.RFC3339, input); err == nil {
+		return v.In(now.Location()), nil
+	}
+
+	for _, layout := range isoLayouts {
+		if v, err := time.ParseInLocation(layout, input, now.Location()); err == nil {
+			return v, nil
+		}
+	}
+
+	lower := strings.ToLower(input)
+	if lower == "now" {
+		return now, nil
+	}
+
+	if d, ok, err := parseRelative(lower); ok {
+		if err != nil {
+			return time.Time{}, TimeParseError{str, err.Error()}
+		}
+		return now.Add(d), nil
+	}
+
+	fields := strings.Fields(lower)
+	fields = mergeMeridiem(fields)
+	if len(fields) == 3 && fields[1] == "at" {
+		fields = []string{fields[0], fields[2]}
+	}
+
+	switch len(fields) {
+	case 1:
+		if date, ok := parseDate(fields[0], now); ok {
+			return date, nil
+		}
+
+		hour, min, sec, err := parseClock(fields[0])
+		if err != nil {
+			return time.Time{}, TimeParseError{str, err.Error()}
+		}
+
+		return time.Date(day.Year(), day.Month(), day.Day(), hour, min, sec, 0, now.Location()), nil
+	case 2:
+		date, ok := parseDate(fields[0], now)
+		if !ok {
+			return time.Time{}, TimeParseError{str, fmt.Sprintf(`unknown day "%s"`, fields[0])}
+		}
+
+		hour, min, sec, err := parseClock(fields[1])
+		if err != nil {
+			return time.Time{}, TimeParseError{str, err.Error()}
+		}
+
+		return time.Date(date.Year(), date.Month(), date.Day(), hour, min, sec, 0, now.Location()), nil
+	default:
+		return time.Time{}, TimeParseError{str, "too many words"}
+	}
+}
+
+// parseRelative parses "2h ago", "-15m", "+1h" and "in 1h30", ok is false if
+// the input is not a relative time at all.
+func parseRelative(str string) (d time.Duration, ok bool, err error) {
+	var sign time.Duration = 1
+
+	switch {
+	case strings.HasSuffix(str, " ago"):
+		str, sign = strings.TrimSuffix(str, " ago"), -1
+	case strings.HasPrefix(str, "in "):
+		str = strings.TrimPrefix(str, "in ")
+	case strings.HasPrefix(str, "-"):
+		str, sign = str[1:], -1
+	case strings.HasPrefix(str, "+"):
+		str = str[1:]
+	default:
+		return 0, false, nil
+	}
+
+	d, err = ParseDuration(strings.ReplaceAll(str, " ", ""))
+	if err != nil {
+		return 0, true, err
+	}
+
+	return sign * d, true, nil
+}
+
+// ParseDuration parses a positive duration such as "15m", "2h" or "1h30".
+func ParseDuration(str string) (time.Duration, error) {
+	if hoursMinRegexp.MatchString(str) {
+		str += "m"
+	}
+
+	if numberRegexp.MatchString(str) {
+		return 0, fmt.Errorf(`ambiguous duration "%s", add a unit (eg. "%sm" or "%sh")`, str, str, str)
+	}
+
+	d, err := time.ParseDuration(str)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf(`invalid duration "%s"`, str)
+	}
+
+	return d, nil
+}
+
+// mergeMeridiem merges a standalone "am"/"pm" with the preceding time.
+func mergeMeridiem(fields []string) []string {
+	ret := make([]string, 0, len(fields))
+	for _, v := range fields {
+		if (v == "am" || v == "pm") && len(ret) > 0 {
+			ret[len(ret)-1] += v
+			continue
+		}
+		ret = append(ret, v)
+	}
+
+	return ret
+}
+
+func parseDate(str string, now time.Time) (time.Time, bool) {
+	today := GetStartOfDay(now)
+
+	switch str {
+	case "today":
+		return today, true
+	case "yesterday":
+		return today.AddDate(0, 0, -1), true
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), true
+	}
+
+	if wd, ok := weekdays[str]; ok {
+		return today.AddDate(0, 0, -((int(today.Weekday()) - int(wd) + 7) % 7)), true
+	}
+
+	if v, err := time.ParseInLocation("2006-01-02", str, now.Location()); err == nil {
+		return v, true
+	}
+
+	return time.Time{}, false
+}
+
+// nolint:cyclop // flat list of formats
+func parseClock(str string) (hour, min, sec int, err error) {
+	var meridiem string
+
+	switch {
+	case str == "noon":
+		return 12, 0, 0, nil
+	case str == "midnight":
+		return 0, 0, 0, nil
+	case numberRegexp.MatchString(str):
+		return 0, 0, 0, fmt.Errorf(`ambiguous time "%s", use eg. "%sh" or "%s:00"`, str, str, str)
+	case clockRegexp.MatchString(str):
+		m := clockRegexp.FindStringSubmatch(str)
+		hour, _ = strconv.Atoi(m[1])
+		min, _ = strconv.Atoi(m[2])
+		if m[3] != "" {
+			sec, _ = strconv.Atoi(m[3])
+		}
+		meridiem = m[4]
+	case hourRegexp.MatchString(str):
+		m := hourRegexp.FindStringSubmatch(str)
+		hour, _ = strconv.Atoi(m[1])
+		if m[2] != "" {
+			min, _ = strconv.Atoi(m[2])
+		}
+	case meridiemRegexp.MatchString(str):
+		m := meridiemRegexp.FindStringSubmatch(str)
+		hour, _ = strconv.Atoi(m[1])
+		meridiem = m[2]
+	default:
+		return 0, 0, 0, fmt.Errorf(`unknown time of day "%s"`, str)
+	}
+
+	if meridiem != "" {
+		if hour < 1 || hour > 12 {
+			return 0, 0, 0, fmt.Errorf(`invalid hour "%s" for a 12-hour clock`, str)
+		}
+		hour %= 12
+		if meridiem == "pm" {
+			hour += 12
+		}
+	}
+
+	if hour > 23 || min > 59 || sec > 59 {
+		return 0, 0, 0, fmt.Errorf(`out of range time of day "%s"`, str)
+	}
+
+	return hour, min, sec, nil
+}
@@ -0,0 +1,100 @@
+package util_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"tt/internal/util"
+)
+
+// Wednesday.
+const parseTimeNow = "2024-03-06T15:04:05+01:00"
+
+var parseTimeTests = []struct {
+	v, expected string
+}{
+	{"now", "2024-03-06T15:04:05+01:00"},
+	{"14:00", "2024-03-06T14:00:00+01:00"},
+	{"  14:00:30 ", "2024-03-06T14:00:30+01:00"},
+	{"9h", "2024-03-06T09:00:00+01:00"},
+	{"9h30", "2024-03-06T09:30:00+01:00"},
+	{"2pm", "2024-03-06T14:00:00+01:00"},
+	{"12 am", "2024-03-06T00:00:00+01:00"},
+	{"9:15PM", "2024-03-06T21:15:00+01:00"},
+	{"noon", "2024-03-06T12:00:00+01:00"},
+	{"midnight", "2024-03-06T00:00:00+01:00"},
+	{"today", "2024-03-06T00:00:00+01:00"},
+	{"yesterday", "2024-03-05T00:00:00+01:00"},
+	{"yesterday 14:00", "2024-03-05T14:00:00+01:00"},
+	{"Yesterday at noon", "2024-03-05T12:00:00+01:00"},
+	{"tomorrow 8h", "2024-03-07T08:00:00+01:00"},
+	{"mon 9h", "2024-03-04T09:00:00+01:00"},
+	{"wednesday 9h", "2024-03-06T09:00:00+01:00"},
+	{"thu", "2024-02-29T00:00:00+01:00"},
+	{"2024-03-01 9h", "2024-03-01T09:00:00+01:00"},
+	{"2h ago", "2024-03-06T13:04:05+01:00"},
+	{"1h 30m ago", "2024-03-06T13:34:05+01:00"},
+	{"-15m", "2024-03-06T14:49:05+01:00"},
+	{"+1h30", "2024-03-06T16:34:05+01:00"},
+	{"in 10m", "2024-03-06T15:14:05+01:00"},
+	{"2024-03-04", "2024-03-04T00:00:00+01:00"},
+	{"2024-03-04 14:00", "2024-03-04T14:00:00+01:00"},
+	{"2024-03-04T14:00:05", "2024-03-04T14:00:05+01:00"},
+	{"2024-03-04T14:00:00Z", "2024-03-04T15:00:00+01:00"},
+	{"2024-03-04T14:00:00+02:00", "2024-03-04T13:00:00+01:00"},
+}
+
+func TestParseTime(t *testing.T) {
+	loc := time.FixedZone("test", 3600)
+	now, err := time.ParseInLocation(time.RFC3339, parseTimeNow, loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range parseTimeTests {
+		actual, err := util.ParseTime(test.v, now)
+		if err != nil {
+			t.Errorf("%s: %s", test.v, err)
+			continue
+		}
+
+		if actual.Format(time.RFC3339) != test.expected {
+			t.Errorf("%s:\n%s\n%s", test.v, actual.Format(time.RFC3339), test.expected)
+		}
+	}
+}
+
+func TestParseTimeOn(t *testing.T) {
+	now := time.Date(2024, 3, 6, 15, 4, 5, 0, time.UTC)
+	day := time.Date(2024, 3, 4, 14, 0, 0, 0, time.UTC)
+
+	actual, err := util.ParseTimeOn("15:30", now, day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := time.Date(2024, 3, 4, 15, 30, 0, 0, time.UTC); !actual.Equal(expected) {
+		t.Errorf("expected %s, got %s", expected, actual)
+	}
+
+	// Relative times are relative to now, not to the day.
+	actual, err = util.ParseTimeOn("1h ago", now, day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := now.Add(-time.Hour); !actual.Equal(expected) {
+		t.Errorf("expected %s, got %s", expected, actual)
+	}
+}
+
+func TestParseTimeErrors(t *testing.T) {
+	for _, v := range []string{
+		"", "9", "25:00", "9:60", "13pm", "someday 9h", "9h foo", "mon tue wed", "2 ago", "-foo", "2024-13-01",
+	} {
+		_, err := util.ParseTime(v, time.Now())
+
+		var parseError util.TimeParseError
+		if !errors.As(err, &parseError) {
+			t.Errorf("%s: expected TimeParseError, got %v", v, err)
+		}
+	}
+}
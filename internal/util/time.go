@@ -53,6 +53,20 @@ func FormatSignedFixedDuration(d time.Duration) string {
 	return fmt.Sprintf("%c%s", sign, FormatFixedDuration(d))
 }
 
+// FormatSpan formats the span of a task, the stop is only dated when it is not
+// on the day of the start. A zero stop is a running task, shown as running.
+func FormatSpan(start, stop time.Time, running string) string {
+	end := running
+	if !stop.IsZero() {
+		end = stop.Format("15:04")
+		if !GetStartOfDay(start).Equal(GetStartOfDay(stop)) {
+			end = stop.Format("2006-01-02 15:04")
+		}
+	}
+
+	return fmt.Sprintf("%s - %s", start.Format("2006-01-02 15:04"), end)
+}
+
 // GetStartOfDay does as it says in a tz-aware timeframe.
 func GetStartOfDay(t time.Time) time.Time {
 	return time.Date(
@@ -73,3 +87,24 @@ func GetStartOfWeek(t time.Time) time.Time {
 func WeekdayOffset(wd time.Weekday) int {
 	return (int(wd) + 6) % 7
 }
+
+// Easter returns the Easter Sunday of the given year in the Gregorian
+// calendar, using the anonymous Gregorian algorithm (Meeus/Jones/Butcher).
+func Easter(year int, loc *time.Location) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := ((h + l - 7*m + 114) % 31) + 1
+
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, loc)
+}
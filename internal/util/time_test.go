@@ -29,6 +29,23 @@ func TestGetStartOfDay(t *testing.T) {
 	}
 }
 
+func TestFormatSpan(t *testing.T) {
+	start := time.Date(2021, 4, 18, 9, 30, 0, 0, time.UTC)
+
+	for _, test := range []struct {
+		stop     time.Time
+		expected string
+	}{
+		{start.Add(2 * time.Hour), "2021-04-18 09:30 - 11:30"},
+		{start.Add(16 * time.Hour), "2021-04-18 09:30 - 2021-04-19 01:30"},
+		{time.Time{}, "2021-04-18 09:30 - now"},
+	} {
+		if actual := util.FormatSpan(start, test.stop, "now"); actual != test.expected {
+			t.Errorf("\n%s\n%s", actual, test.expected)
+		}
+	}
+}
+
 var startOfWeekTests = []struct {
 	v, expected string
 }{
@@ -55,3 +72,23 @@ func TestGetStartOfWeek(t *testing.T) {
 		}
 	}
 }
+
+var easterTests = []struct {
+	year     int
+	expected string
+}{
+	{1961, "1961-04-02"},
+	{2000, "2000-04-23"},
+	{2019, "2019-04-21"},
+	{2022, "2022-04-17"},
+	{2024, "2024-03-31"},
+	{2038, "2038-04-25"},
+}
+
+func TestEaster(t *testing.T) {
+	for _, test := range easterTests {
+		if actual := util.Easter(test.year, time.UTC).Format("2006-01-02"); actual != test.expected {
+			t.Errorf("\n%s\n%s", actual, test.expected)
+		}
+	}
+}